@@ -112,3 +112,16 @@ func TestLoadOptionsFromProvider_MergeAll(t *testing.T) {
 		t.Fatalf("bad opts: %+v", opts)
 	}
 }
+
+func TestLoadOptionsFromContents_SkipDeleted(t *testing.T) {
+	opts, err := LoadOptionsFromContents([]provider.Content{
+		{ID: "a", Payload: "server:\n  bind: ':1'\n"},
+		{ID: "b", Deleted: true},
+	})
+	if err != nil || opts.Server.Bind != ":1" {
+		t.Fatalf("bad opts: %v %+v", err, opts)
+	}
+	if _, err := LoadOptionsFromContents([]provider.Content{{ID: "b", Deleted: true}}); err == nil {
+		t.Fatalf("want error")
+	}
+}
@@ -50,18 +50,29 @@ func LoadFromProvider(p provider.Provider, opts any) error {
 // LoadOptionsFromProvider 通过 Provider 读取第一个配置文档并解析为 Options。
 // 会为缺省端口设置默认值。
 func LoadOptionsFromProvider(p provider.Provider) (Options, error) {
-	var out Options
 	contents, err := p.Open()
 	if err != nil {
-		return out, err
-	}
-	if len(contents) == 0 {
-		return out, errors.New("no config content from provider")
+		return Options{}, err
 	}
+	return LoadOptionsFromContents(contents)
+}
+
+// LoadOptionsFromContents 依次合并各配置文档并解析为 Options，跳过已删除的条目。
+// 会为缺省端口设置默认值。
+func LoadOptionsFromContents(contents []provider.Content) (Options, error) {
+	var out Options
+	n := 0
 	for _, c := range contents {
+		if c.Deleted {
+			continue
+		}
 		if err := yaml.Unmarshal([]byte(c.Payload), &out); err != nil {
 			return out, fmt.Errorf("parse yaml: %w", err)
 		}
+		n++
+	}
+	if n == 0 {
+		return out, errors.New("no config content from provider")
 	}
 	if out.Server.Bind == "" {
 		out.Server.Bind = ":8080"
@@ -0,0 +1,331 @@
+package provider
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ApolloProvider 通过 Apollo 配置服务的 configs 接口读取多个命名空间（每个命名空间一个 Content，
+// 按 Namespaces 顺序合并），并通过 /notifications/v2 长轮询订阅变更。
+// properties 命名空间的 key 按 "." 拆分为层级转换为 YAML；yaml/yml/json 等命名空间直接使用其内容。
+type ApolloProvider struct {
+	ServerURL  string // 配置服务地址，例如 http://apollo-config:8080
+	AppID      string
+	Cluster    string   // 默认 default
+	Namespaces []string // 默认 application
+	Secret     string   // 访问密钥，开启后按 Apollo 规则签名；支持 file:/path 与 env:NAME
+	ClientIP   string   // 上报的客户端 IP，用于灰度发布规则
+	Timeout    time.Duration
+
+	LongPollTimeout time.Duration // 长轮询请求超时，需大于服务端挂起的 60s，默认 90s
+	RetryInterval   time.Duration // 长轮询或读取失败后首次重试的间隔，默认 1s
+
+	watcher
+	mu            sync.Mutex
+	cli           *http.Client
+	contents      map[string]Content // 按命名空间缓存，配合 releaseKey 处理 304
+	notifications map[string]int64   // 按命名空间记录最近的 notificationId
+}
+
+func NewApollo(serverURL, appID string, namespaces ...string) *ApolloProvider {
+	return &ApolloProvider{ServerURL: serverURL, AppID: appID, Cluster: "default", Namespaces: namespaces,
+		Timeout: 10 * time.Second, LongPollTimeout: 90 * time.Second, RetryInterval: time.Second}
+}
+
+func (p *ApolloProvider) namespaces() []string {
+	if len(p.Namespaces) == 0 {
+		return []string{"application"}
+	}
+	return p.Namespaces
+}
+
+func (p *ApolloProvider) cluster() string {
+	if p.Cluster == "" {
+		return "default"
+	}
+	return p.Cluster
+}
+
+// apolloName 返回服务端使用的命名空间名：properties 命名空间不带扩展名。
+func apolloName(ns string) string {
+	return strings.TrimSuffix(ns, ".properties")
+}
+
+func (p *ApolloProvider) client() *http.Client {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cli == nil {
+		p.cli = &http.Client{}
+	}
+	return p.cli
+}
+
+func (p *ApolloProvider) Open() ([]Content, error) {
+	out := make([]Content, 0, len(p.namespaces()))
+	for _, ns := range p.namespaces() {
+		c, err := p.fetch(ns)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// fetch 读取一个命名空间，携带上次的 releaseKey，未变化时服务端返回 304 并复用缓存。
+func (p *ApolloProvider) fetch(ns string) (Content, error) {
+	if p.ServerURL == "" || p.AppID == "" {
+		return Content{}, errors.New("apollo server url and app id are required")
+	}
+	p.mu.Lock()
+	cached, ok := p.contents[ns]
+	p.mu.Unlock()
+	q := url.Values{}
+	if ok {
+		q.Set("releaseKey", cached.Meta["releaseKey"])
+	}
+	if p.ClientIP != "" {
+		q.Set("ip", p.ClientIP)
+	}
+	u := fmt.Sprintf("/configs/%s/%s/%s", url.PathEscape(p.AppID), url.PathEscape(p.cluster()), url.PathEscape(apolloName(ns)))
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	resp, err := p.do(context.Background(), u, p.Timeout)
+	if err != nil {
+		return Content{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && ok {
+		return cached, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return Content{}, fmt.Errorf("apollo get %s: %s: %s", ns, resp.Status, strings.TrimSpace(string(body)))
+	}
+	var body struct {
+		Configurations map[string]string `json:"configurations"`
+		ReleaseKey     string            `json:"releaseKey"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Content{}, fmt.Errorf("apollo get %s: %w", ns, err)
+	}
+	payload, err := apolloPayload(ns, body.Configurations)
+	if err != nil {
+		return Content{}, fmt.Errorf("apollo namespace %s: %w", ns, err)
+	}
+	c := Content{ID: ns, Group: "apollo", Payload: payload, Meta: map[string]string{"releaseKey": body.ReleaseKey}}
+	p.mu.Lock()
+	if p.contents == nil {
+		p.contents = map[string]Content{}
+	}
+	p.contents[ns] = c
+	p.mu.Unlock()
+	return c, nil
+}
+
+// apolloPayload 将命名空间内容转换为 YAML 文档。
+func apolloPayload(ns string, configurations map[string]string) (string, error) {
+	switch ext := path.Ext(ns); ext {
+	case "", ".properties":
+	default:
+		// 非 properties 命名空间的原文保存在 content 中
+		return configurations["content"], nil
+	}
+	return propertiesYAML(configurations)
+}
+
+// do 发起签名的 GET 请求，pathQuery 为以 / 开头的路径及查询串。
+func (p *ApolloProvider) do(ctx context.Context, pathQuery string, timeout time.Duration) (*http.Response, error) {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(p.ServerURL, "/")+pathQuery, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if p.Secret != "" {
+		secret, err := ResolveSecret(p.Secret)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("apollo secret: %w", err)
+		}
+		ts := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		req.Header.Set("Authorization", "Apollo "+p.AppID+":"+apolloSignature(ts, pathQuery, secret))
+		req.Header.Set("Timestamp", ts)
+	}
+	resp, err := p.client().Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// apolloSignature 计算 Base64(HmacSHA1(secret, timestamp + "\n" + pathWithQuery))。
+func apolloSignature(timestamp, pathWithQuery, secret string) string {
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + pathWithQuery))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// cancelBody 在关闭响应体时释放请求的 context。
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func (p *ApolloProvider) Watch(onChange func() error) error {
+	return p.WatchContents(func([]Content) error { return onChange() })
+}
+
+// WatchContents 通过 /notifications/v2 长轮询，任一命名空间发布后重新读取该命名空间，
+// 并携带全部命名空间的内容通知。
+func (p *ApolloProvider) WatchContents(onChange func([]Content) error) error {
+	p.startWatch(0, p.RetryInterval, func(ctx context.Context) error {
+		changed, first, err := p.poll(ctx)
+		if err != nil {
+			return err
+		}
+		stale, err := p.resync(first)
+		if err != nil {
+			return err
+		}
+		if len(changed)+len(stale) == 0 {
+			return nil
+		}
+		contents, err := p.refresh(changed)
+		if err == nil {
+			_ = onChange(contents)
+		}
+		return err
+	})
+	return nil
+}
+
+type apolloNotification struct {
+	NamespaceName  string `json:"namespaceName"`
+	NotificationID int64  `json:"notificationId"`
+}
+
+// poll 发起一次长轮询，返回有新发布的命名空间，以及首次取得 notificationId 的命名空间；
+// 服务端超时返回 304 时均为空。
+func (p *ApolloProvider) poll(ctx context.Context) (changed, first []string, err error) {
+	p.mu.Lock()
+	if p.notifications == nil {
+		p.notifications = map[string]int64{}
+	}
+	var req []apolloNotification
+	byName := map[string]string{}
+	for _, ns := range p.namespaces() {
+		id, ok := p.notifications[ns]
+		if !ok {
+			id = -1
+		}
+		req = append(req, apolloNotification{NamespaceName: apolloName(ns), NotificationID: id})
+		byName[apolloName(ns)] = ns
+	}
+	p.mu.Unlock()
+	b, err := json.Marshal(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	q := url.Values{"appId": {p.AppID}, "cluster": {p.cluster()}, "notifications": {string(b)}}
+	timeout := p.LongPollTimeout
+	if timeout <= 0 {
+		timeout = 90 * time.Second
+	}
+	resp, err := p.do(ctx, "/notifications/v2?"+q.Encode(), timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, nil, fmt.Errorf("apollo notifications: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var got []apolloNotification
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		return nil, nil, fmt.Errorf("apollo notifications: %w", err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, n := range got {
+		ns, ok := byName[n.NamespaceName]
+		if !ok {
+			continue
+		}
+		if prev, seen := p.notifications[ns]; !seen {
+			first = append(first, ns)
+		} else if prev != n.NotificationID {
+			changed = append(changed, ns)
+		}
+		p.notifications[ns] = n.NotificationID
+	}
+	return changed, first, nil
+}
+
+// resync 处理首次长轮询的结果：id 为 -1 的请求只能得到当前的 notificationId，无法判断 Open 之后
+// 是否又有发布，因此携带 Open 时的 releaseKey 重新读取，返回 releaseKey 已变化的命名空间。
+func (p *ApolloProvider) resync(namespaces []string) ([]string, error) {
+	var stale []string
+	for _, ns := range namespaces {
+		p.mu.Lock()
+		prev := p.contents[ns].Meta["releaseKey"]
+		p.mu.Unlock()
+		c, err := p.fetch(ns)
+		if err != nil {
+			return stale, err
+		}
+		if c.Meta["releaseKey"] != prev {
+			stale = append(stale, ns)
+		}
+	}
+	return stale, nil
+}
+
+// refresh 绕过 releaseKey 缓存重新读取有新发布的命名空间，返回全部命名空间的内容。
+func (p *ApolloProvider) refresh(namespaces []string) ([]Content, error) {
+	p.mu.Lock()
+	for _, ns := range namespaces {
+		delete(p.contents, ns)
+	}
+	p.mu.Unlock()
+	return p.Open()
+}
+
+// Close 停止长轮询。
+func (p *ApolloProvider) Close() error {
+	p.stopWatch()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cli != nil {
+		p.cli.CloseIdleConnections()
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package provider
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// apolloStub 模拟 Apollo 配置服务的 configs 与 notifications/v2 接口，并校验签名。
+type apolloStub struct {
+	mu       sync.Mutex
+	secret   string
+	configs  map[string]map[string]string // 命名空间 -> configurations
+	releases map[string]int64             // 命名空间 -> 发布序号，兼作 releaseKey 与 notificationId
+	changed  chan struct{}
+	fetches  int
+}
+
+func newApolloStub() *apolloStub {
+	return &apolloStub{secret: "s3cret", configs: map[string]map[string]string{}, releases: map[string]int64{}, changed: make(chan struct{})}
+}
+
+func (s *apolloStub) publish(ns string, cfg map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configs[ns] = cfg
+	s.releases[ns]++
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *apolloStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	pathQuery := r.URL.EscapedPath()
+	if r.URL.RawQuery != "" {
+		pathQuery += "?" + r.URL.RawQuery
+	}
+	want := "Apollo demo:" + apolloSignature(r.Header.Get("Timestamp"), pathQuery, s.secret)
+	if r.Header.Get("Authorization") != want {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/configs/demo/default/"):
+		ns := strings.TrimPrefix(r.URL.Path, "/configs/demo/default/")
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.fetches++
+		cfg, ok := s.configs[ns]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		key := ns + "-" + itoa(s.releases[ns])
+		if r.URL.Query().Get("releaseKey") == key {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"namespaceName": ns, "configurations": cfg, "releaseKey": key})
+	case r.URL.Path == "/notifications/v2":
+		var req []apolloNotification
+		if err := json.Unmarshal([]byte(r.URL.Query().Get("notifications")), &req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for {
+			s.mu.Lock()
+			var out []apolloNotification
+			for _, n := range req {
+				if id := s.releases[n.NamespaceName]; id != n.NotificationID {
+					out = append(out, apolloNotification{NamespaceName: n.NamespaceName, NotificationID: id})
+				}
+			}
+			changed := s.changed
+			s.mu.Unlock()
+			if len(out) > 0 {
+				_ = json.NewEncoder(w).Encode(out)
+				return
+			}
+			select {
+			case <-changed:
+			case <-time.After(2 * time.Second):
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func itoa(n int64) string {
+	b, _ := json.Marshal(n)
+	return string(b)
+}
+
+func TestApollo_OpenNamespaces(t *testing.T) {
+	stub := newApolloStub()
+	stub.publish("application", map[string]string{"server.bind": ":9090", "welcome.message": "hi", "feature.enabled": "true"})
+	stub.publish("app.yaml", map[string]string{"content": "welcome:\n  message: from yaml\n"})
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	p := NewApollo(srv.URL, "demo", "application", "app.yaml")
+	p.Secret = "s3cret"
+	cs, err := p.Open()
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if len(cs) != 2 || cs[0].ID != "application" || cs[1].ID != "app.yaml" {
+		t.Fatalf("contents: %+v", cs)
+	}
+	if !strings.Contains(cs[0].Payload, "bind: :9090") || !strings.Contains(cs[0].Payload, "enabled: true") {
+		t.Fatalf("properties payload: %q", cs[0].Payload)
+	}
+	if cs[1].Payload != "welcome:\n  message: from yaml\n" || cs[1].Meta["releaseKey"] != "app.yaml-1" {
+		t.Fatalf("yaml content: %+v", cs[1])
+	}
+	// 再次读取使用 releaseKey，未变化时复用缓存
+	if cs2, err := p.Open(); err != nil || cs2[0].Payload != cs[0].Payload {
+		t.Fatalf("reopen: %+v %v", cs2, err)
+	}
+
+	p.Secret = "wrong"
+	if _, err := p.Open(); err == nil {
+		t.Fatalf("want signature error")
+	}
+}
+
+func TestApollo_WatchNotifications(t *testing.T) {
+	stub := newApolloStub()
+	stub.publish("application", map[string]string{"a": "1"})
+	stub.publish("db.yaml", map[string]string{"content": "b: 1\n"})
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	p := NewApollo(srv.URL, "demo", "application", "db.yaml")
+	p.Secret = "s3cret"
+	defer p.Close()
+	if _, err := p.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	got := make(chan []Content, 4)
+	if err := p.WatchContents(func(cs []Content) error { got <- cs; return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	stub.publish("db.yaml", map[string]string{"content": "b: 2\n"})
+	select {
+	case cs := <-got:
+		if len(cs) != 2 || cs[0].Payload != "a: 1\n" || cs[1].Payload != "b: 2\n" {
+			t.Fatalf("contents: %+v", cs)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no change")
+	}
+	if !p.Health().OK {
+		t.Fatalf("want healthy")
+	}
+}
+
+func TestApollo_WatchReleaseBeforeFirstPoll(t *testing.T) {
+	stub := newApolloStub()
+	stub.publish("application", map[string]string{"a": "1"})
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	p := NewApollo(srv.URL, "demo", "application")
+	p.Secret = "s3cret"
+	defer p.Close()
+	if _, err := p.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	// Open 之后、首次长轮询之前的发布：首次响应只带回当前的 notificationId，需按 releaseKey 补读
+	stub.publish("application", map[string]string{"a": "2"})
+	got := make(chan []Content, 4)
+	if err := p.WatchContents(func(cs []Content) error { got <- cs; return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	select {
+	case cs := <-got:
+		if len(cs) != 1 || cs[0].Payload != "a: 2\n" || cs[0].Meta["releaseKey"] != "application-2" {
+			t.Fatalf("contents: %+v", cs)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("release before first poll lost")
+	}
+}
+
+func TestApolloPayloadConflict(t *testing.T) {
+	if _, err := apolloPayload("application", map[string]string{"a": "1", "a.b": "2"}); err == nil {
+		t.Fatalf("want conflict error")
+	}
+}
@@ -0,0 +1,208 @@
+package provider
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OpenArchive 将 zip、tar、tar.gz/tgz 归档读入内存并以 fs.FS 返回，按扩展名识别格式。
+func OpenArchive(file string) (fs.FS, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	lower := strings.ToLower(file)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+		if err != nil {
+			return nil, fmt.Errorf("archive %s: %w", file, err)
+		}
+		return zr, nil
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		gz, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("archive %s: %w", file, err)
+		}
+		defer gz.Close()
+		return readTar(file, gz)
+	case strings.HasSuffix(lower, ".tar"):
+		return readTar(file, bytes.NewReader(b))
+	}
+	return nil, fmt.Errorf("archive %s: unsupported format, want .zip, .tar, .tar.gz or .tgz", file)
+}
+
+func readTar(file string, r io.Reader) (fs.FS, error) {
+	m := newMemFS()
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return m, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("archive %s: %w", file, err)
+		}
+		if slices.Contains(strings.Split(h.Name, "/"), "..") {
+			return nil, fmt.Errorf("archive %s: %s: entry path contains ..", file, h.Name)
+		}
+		name := path.Clean(h.Name)
+		if name == "." {
+			continue
+		}
+		if !fs.ValidPath(name) {
+			return nil, fmt.Errorf("archive %s: %s: invalid entry path", file, h.Name)
+		}
+		switch h.Typeflag {
+		case tar.TypeDir:
+			_, err = m.mkdir(name, h.ModTime)
+		case tar.TypeReg:
+			var data []byte
+			if data, err = io.ReadAll(tr); err == nil {
+				err = m.add(name, data, h.ModTime)
+			}
+		}
+		// 链接等其他类型的条目被忽略
+		if err != nil {
+			return nil, fmt.Errorf("archive %s: %s: %w", file, name, err)
+		}
+	}
+}
+
+// memFS 是只读的内存文件系统，目录由文件路径推导。
+type memFS map[string]*memEntry
+
+type memEntry struct {
+	name     string
+	data     []byte
+	dir      bool
+	modTime  time.Time
+	children map[string]*memEntry
+}
+
+func newMemFS() memFS {
+	return memFS{".": {name: ".", dir: true, children: map[string]*memEntry{}}}
+}
+
+// mkdir 创建目录及其缺失的上级目录，路径中已有同名文件时返回错误。
+func (m memFS) mkdir(name string, mod time.Time) (*memEntry, error) {
+	if e, ok := m[name]; ok {
+		if !e.dir {
+			return nil, fmt.Errorf("%s is a file", name)
+		}
+		return e, nil
+	}
+	parent, err := m.mkdir(path.Dir(name), mod)
+	if err != nil {
+		return nil, err
+	}
+	e := &memEntry{name: path.Base(name), dir: true, modTime: mod, children: map[string]*memEntry{}}
+	m[name], parent.children[e.name] = e, e
+	return e, nil
+}
+
+// add 添加一个文件，同名的文件或目录已存在时返回错误。
+func (m memFS) add(name string, data []byte, mod time.Time) error {
+	if _, ok := m[name]; ok {
+		return errors.New("duplicate entry")
+	}
+	parent, err := m.mkdir(path.Dir(name), mod)
+	if err != nil {
+		return err
+	}
+	e := &memEntry{name: path.Base(name), data: data, modTime: mod}
+	m[name], parent.children[e.name] = e, e
+	return nil
+}
+
+func (m memFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	e, ok := m[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &memFile{memEntry: e, r: bytes.NewReader(e.data)}, nil
+}
+
+func (e *memEntry) readDir() []fs.DirEntry {
+	out := make([]fs.DirEntry, 0, len(e.children))
+	for _, c := range e.children {
+		out = append(out, fs.FileInfoToDirEntry(c))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name() < out[j].Name() })
+	return out
+}
+
+// ReadDir 实现 fs.ReadDirFS，按名称排序返回目录项。
+func (m memFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	e, ok := m[name]
+	if !ok || !e.dir {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	return e.readDir(), nil
+}
+
+// memEntry 同时实现 fs.FileInfo。
+func (e *memEntry) Name() string       { return e.name }
+func (e *memEntry) Size() int64        { return int64(len(e.data)) }
+func (e *memEntry) ModTime() time.Time { return e.modTime }
+func (e *memEntry) IsDir() bool        { return e.dir }
+func (e *memEntry) Sys() any           { return nil }
+func (e *memEntry) Mode() fs.FileMode {
+	if e.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+type memFile struct {
+	*memEntry
+	r       *bytes.Reader
+	entries []fs.DirEntry // 目录的子项，首次 ReadDir 时填充
+	off     int
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.memEntry, nil }
+func (f *memFile) Close() error               { return nil }
+
+func (f *memFile) Read(b []byte) (int, error) {
+	if f.dir {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: errors.New("is a directory")}
+	}
+	return f.r.Read(b)
+}
+
+// ReadDir 实现 fs.ReadDirFile。
+func (f *memFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !f.dir {
+		return nil, &fs.PathError{Op: "readdir", Path: f.name, Err: errors.New("not a directory")}
+	}
+	if f.entries == nil {
+		f.entries = f.readDir()
+	}
+	rest := f.entries[f.off:]
+	if n <= 0 {
+		f.off = len(f.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(rest))
+	f.off += n
+	return rest[:n], nil
+}
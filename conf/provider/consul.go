@@ -0,0 +1,222 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ConsulProvider 通过 Consul KV HTTP API 读取一个 key，或 Prefix 为 true 时读取前缀下的全部 key，
+// 使用阻塞查询（index + wait）订阅变更。Content.Revision 为该 key 的 ModifyIndex。
+type ConsulProvider struct {
+	Address    string // 例如 http://127.0.0.1:8500，未写协议时默认 http
+	Key        string
+	Prefix     bool
+	Token      string // ACL token，支持 file:/path 与 env:NAME，见 ResolveSecret
+	Datacenter string
+	TLS        TLSOptions
+	Timeout    time.Duration // 非阻塞请求的超时，默认 10s
+
+	Wait          time.Duration // 阻塞查询的最长等待时间，默认 5m
+	RetryInterval time.Duration // 查询失败后首次重试的间隔，默认 1s
+
+	watcher
+	mu    sync.Mutex
+	cli   *http.Client
+	index uint64 // 最近一次响应的 X-Consul-Index
+	sig   string // 最近一次内容的签名，用于过滤无关的 index 变化
+}
+
+func NewConsul(address, key string) *ConsulProvider {
+	return &ConsulProvider{Address: address, Key: key, Timeout: 10 * time.Second, Wait: 5 * time.Minute, RetryInterval: time.Second}
+}
+
+// NewConsulPrefix 创建前缀模式的 ConsulProvider，读取并监听 prefix 下的全部 key。
+func NewConsulPrefix(address, prefix string) *ConsulProvider {
+	p := NewConsul(address, prefix)
+	p.Prefix = true
+	return p
+}
+
+func (p *ConsulProvider) client() (*http.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cli != nil {
+		return p.cli, nil
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	if p.TLS.Enabled() {
+		cfg, err := p.TLS.ConfigFor(tlsHost(p.Address))
+		if err != nil {
+			return nil, err
+		}
+		tr.TLSClientConfig = cfg
+	}
+	p.cli = &http.Client{Transport: tr}
+	return p.cli, nil
+}
+
+func (p *ConsulProvider) Open() ([]Content, error) {
+	out, index, err := p.get(context.Background(), 0)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.index, p.sig = index, consulSignature(out)
+	p.mu.Unlock()
+	return out, nil
+}
+
+type consulKV struct {
+	Key         string
+	Value       *string // base64，目录节点为 null
+	ModifyIndex uint64
+}
+
+// get 读取 KV；index 大于 0 时发起阻塞查询，直到 index 变化或超过 Wait。
+func (p *ConsulProvider) get(ctx context.Context, index uint64) ([]Content, uint64, error) {
+	if p.Address == "" || (p.Key == "" && !p.Prefix) {
+		return nil, 0, errors.New("consul address and key are required")
+	}
+	cli, err := p.client()
+	if err != nil {
+		return nil, 0, err
+	}
+	base := strings.TrimSuffix(p.Address, "/")
+	if !strings.Contains(base, "://") {
+		base = "http://" + base
+	}
+	q := url.Values{}
+	if p.Prefix {
+		q.Set("recurse", "true")
+	}
+	if p.Datacenter != "" {
+		q.Set("dc", p.Datacenter)
+	}
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	if index > 0 {
+		wait := p.Wait
+		if wait <= 0 {
+			wait = 5 * time.Minute
+		}
+		q.Set("index", strconv.FormatUint(index, 10))
+		q.Set("wait", fmt.Sprintf("%ds", int(wait/time.Second)))
+		// Consul 会在 wait 基础上随机增加最多 1/16 的时间
+		timeout += wait + wait/16
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	u := base + "/v1/kv/" + strings.TrimPrefix(p.Key, "/") + "?" + q.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if p.Token != "" {
+		token, err := ResolveSecret(p.Token)
+		if err != nil {
+			return nil, 0, fmt.Errorf("consul token: %w", err)
+		}
+		req.Header.Set("X-Consul-Token", token)
+	}
+	resp, err := cli.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	newIndex, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	if resp.StatusCode == http.StatusNotFound {
+		return []Content{}, newIndex, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, 0, fmt.Errorf("consul get %s: %s: %s", p.Key, resp.Status, strings.TrimSpace(string(body)))
+	}
+	var kvs []consulKV
+	if err := json.NewDecoder(resp.Body).Decode(&kvs); err != nil {
+		return nil, 0, fmt.Errorf("consul get %s: %w", p.Key, err)
+	}
+	out := make([]Content, 0, len(kvs))
+	for _, kv := range kvs {
+		if kv.Value == nil {
+			continue
+		}
+		b, err := base64.StdEncoding.DecodeString(*kv.Value)
+		if err != nil {
+			return nil, 0, fmt.Errorf("consul key %s: %w", kv.Key, err)
+		}
+		out = append(out, Content{ID: kv.Key, Group: "consul", Payload: string(b), Revision: int64(kv.ModifyIndex)})
+	}
+	return out, newIndex, nil
+}
+
+// consulSignature 以 key 与 ModifyIndex 标识一组内容。
+func consulSignature(contents []Content) string {
+	var b strings.Builder
+	for _, c := range contents {
+		fmt.Fprintf(&b, "%s@%d;", c.ID, c.Revision)
+	}
+	return b.String()
+}
+
+func (p *ConsulProvider) Watch(onChange func() error) error {
+	return p.WatchContents(func([]Content) error { return onChange() })
+}
+
+// WatchContents 在后台循环发起阻塞查询，内容变化时携带全部内容通知。
+func (p *ConsulProvider) WatchContents(onChange func([]Content) error) error {
+	if _, err := p.client(); err != nil {
+		return err
+	}
+	p.startWatch(0, p.RetryInterval, func(ctx context.Context) error { return p.check(ctx, onChange) })
+	return nil
+}
+
+// check 以上次的 index 发起一次阻塞查询，内容变化时通知。
+func (p *ConsulProvider) check(ctx context.Context, onChange func([]Content) error) error {
+	p.mu.Lock()
+	index, sig := p.index, p.sig
+	p.mu.Unlock()
+	out, newIndex, err := p.get(ctx, index)
+	if err != nil {
+		return err
+	}
+	// index 回退（例如快照恢复）时从头开始；index 需始终大于 0，否则查询不会阻塞
+	if newIndex < index {
+		newIndex = 0
+	}
+	if newIndex == 0 {
+		newIndex = 1
+	}
+	newSig := consulSignature(out)
+	p.mu.Lock()
+	p.index, p.sig = newIndex, newSig
+	p.mu.Unlock()
+	p.setRevision(int64(newIndex))
+	if index > 0 && newSig != sig {
+		_ = onChange(out)
+	}
+	return nil
+}
+
+// Close 停止监听。
+func (p *ConsulProvider) Close() error {
+	p.stopWatch()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cli != nil {
+		p.cli.CloseIdleConnections()
+	}
+	return nil
+}
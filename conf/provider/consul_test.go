@@ -0,0 +1,161 @@
+package provider
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// consulStub 是 Consul KV HTTP API 的最小替身，支持 recurse、dc 与阻塞查询。
+type consulStub struct {
+	mu      sync.Mutex
+	index   uint64
+	kvs     map[string]consulKV
+	changed chan struct{}
+	token   string
+	lastDC  string
+}
+
+func newConsulStub() *consulStub {
+	return &consulStub{index: 1, kvs: map[string]consulKV{}, changed: make(chan struct{}), token: "secret"}
+}
+
+func (s *consulStub) put(key, val string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index++
+	v := base64.StdEncoding.EncodeToString([]byte(val))
+	s.kvs[key] = consulKV{Key: key, Value: &v, ModifyIndex: s.index}
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *consulStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Consul-Token") != s.token {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	q := r.URL.Query()
+	s.mu.Lock()
+	s.lastDC = q.Get("dc")
+	cur, changed := s.index, s.changed
+	s.mu.Unlock()
+	if idx, _ := strconv.ParseUint(q.Get("index"), 10, 64); idx >= cur {
+		wait, _ := time.ParseDuration(q.Get("wait"))
+		select {
+		case <-changed:
+		case <-time.After(wait):
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []consulKV
+	for k, kv := range s.kvs {
+		if k == key || (q.Has("recurse") && strings.HasPrefix(k, key)) {
+			out = append(out, kv)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(s.index, 10))
+	if len(out) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+func TestConsul_OpenPrefix(t *testing.T) {
+	stub := newConsulStub()
+	stub.put("app/b.yaml", "b: 1\n")
+	stub.put("app/a.yaml", "a: 1\n")
+	stub.put("other", "x: 1\n")
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	p := NewConsulPrefix(srv.URL, "app/")
+	p.Token = "secret"
+	p.Datacenter = "dc2"
+	cs, err := p.Open()
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if len(cs) != 2 || cs[0].ID != "app/a.yaml" || cs[0].Payload != "a: 1\n" || cs[0].Revision != 3 || cs[1].Revision != 2 {
+		t.Fatalf("contents: %+v", cs)
+	}
+	if stub.lastDC != "dc2" {
+		t.Fatalf("dc not sent: %q", stub.lastDC)
+	}
+
+	missing := NewConsul(srv.URL, "nope")
+	missing.Token = "secret"
+	if cs, err := missing.Open(); err != nil || len(cs) != 0 {
+		t.Fatalf("missing key: %+v %v", cs, err)
+	}
+	denied := NewConsul(srv.URL, "other")
+	if _, err := denied.Open(); err == nil {
+		t.Fatalf("want acl error")
+	}
+}
+
+func TestConsul_WatchBlocking(t *testing.T) {
+	stub := newConsulStub()
+	stub.put("app/a.yaml", "a: 1\n")
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	p := NewConsulPrefix(srv.URL, "app/")
+	p.Token = "secret"
+	p.Wait = 2 * time.Second
+	defer p.Close()
+	if _, err := p.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	got := make(chan []Content, 4)
+	if err := p.WatchContents(func(cs []Content) error { got <- cs; return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	// 前缀外的变化会推进 index，但不应触发重新加载
+	stub.put("other", "x: 1\n")
+	stub.put("app/a.yaml", "a: 2\n")
+	select {
+	case cs := <-got:
+		if len(cs) != 1 || cs[0].Payload != "a: 2\n" || cs[0].Revision != 4 {
+			t.Fatalf("contents: %+v", cs)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no change")
+	}
+	select {
+	case cs := <-got:
+		t.Fatalf("unexpected notify: %+v", cs)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if h := p.Health(); !h.OK || h.Revision != 4 {
+		t.Fatalf("health: %+v", h)
+	}
+}
+
+func TestConsul_WatchHealth(t *testing.T) {
+	p := NewConsul("127.0.0.1:1", "app")
+	p.RetryInterval = 10 * time.Millisecond
+	defer p.Close()
+	if err := p.Watch(func() error { return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for p.Health().OK {
+		if time.Now().After(deadline) {
+			t.Fatalf("want unhealthy")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,148 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvProvider 将带指定前缀的环境变量组装为一个 YAML 文档。
+// 变量名去掉前缀后按 Separator（默认 "__"）拆分为层级并转为小写，
+// 例如 APP_SERVER__BIND=:9090 对应 server.bind。
+// 变量值按 YAML 标量/序列解析，解析失败时按原始字符串处理。
+// Prefix 不能为空，否则进程的全部环境变量（PATH、HOME 等）都会被当作配置。
+type EnvProvider struct {
+	Prefix    string
+	Separator string
+
+	environ func() []string
+}
+
+func NewEnv(prefix string) *EnvProvider {
+	return &EnvProvider{Prefix: prefix, Separator: "__", environ: os.Environ}
+}
+
+func (p *EnvProvider) Open() ([]Content, error) {
+	doc, err := p.document()
+	if err != nil {
+		return nil, err
+	}
+	b, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return []Content{{ID: p.Prefix, Group: "env", Payload: string(b)}}, nil
+}
+
+// Watch 进程内环境变量不会被外部修改，因此无需监听。
+func (p *EnvProvider) Watch(onChange func() error) error {
+	return nil
+}
+
+func (p *EnvProvider) document() (map[string]any, error) {
+	if p.Prefix == "" {
+		return nil, errors.New("env prefix is required")
+	}
+	environ := p.environ
+	if environ == nil {
+		environ = os.Environ
+	}
+	sep := p.Separator
+	if sep == "" {
+		sep = "__"
+	}
+	vars := map[string]string{}
+	var names []string
+	for _, kv := range environ() {
+		name, val, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, p.Prefix) {
+			continue
+		}
+		key := strings.ToLower(strings.TrimPrefix(name, p.Prefix))
+		if key == "" {
+			continue
+		}
+		vars[key] = val
+		names = append(names, key)
+	}
+	sort.Strings(names)
+
+	doc := map[string]any{}
+	for _, key := range names {
+		if err := setPath(doc, strings.Split(key, sep), envValue(vars[key])); err != nil {
+			return nil, fmt.Errorf("env %s%s: %w", p.Prefix, strings.ToUpper(key), err)
+		}
+	}
+	return doc, nil
+}
+
+// setPath 按路径在嵌套 map 中写入值，路径与已有的叶子值冲突时返回错误。
+func setPath(doc map[string]any, path []string, val any) error {
+	node := doc
+	for i, seg := range path {
+		if seg == "" {
+			return fmt.Errorf("empty path segment")
+		}
+		if i == len(path)-1 {
+			if _, ok := node[seg].(map[string]any); ok {
+				return fmt.Errorf("%q is already a section", strings.Join(path, "."))
+			}
+			node[seg] = val
+			return nil
+		}
+		next, exists := node[seg]
+		if !exists {
+			m := map[string]any{}
+			node[seg] = m
+			node = m
+			continue
+		}
+		m, ok := next.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%q is already a value", strings.Join(path[:i+1], "."))
+		}
+		node = m
+	}
+	return nil
+}
+
+// propertiesYAML 将按 "." 分隔层级的 key/value 转换为 YAML 文档，值按 envValue 的规则解析，
+// 供以 properties 形式保存配置的 Provider 共用。
+func propertiesYAML(props map[string]string) (string, error) {
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	doc := map[string]any{}
+	for _, k := range keys {
+		if err := setPath(doc, strings.Split(k, "."), envValue(props[k])); err != nil {
+			return "", fmt.Errorf("key %s: %w", k, err)
+		}
+	}
+	b, err := yaml.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// envValue 尝试将变量值解析为 YAML 标量或序列，以便表达数字、布尔与列表。
+func envValue(raw string) any {
+	if strings.TrimSpace(raw) == "" {
+		return raw
+	}
+	var v any
+	if err := yaml.Unmarshal([]byte(raw), &v); err != nil {
+		return raw
+	}
+	switch v.(type) {
+	case map[string]any, nil:
+		return raw
+	}
+	return v
+}
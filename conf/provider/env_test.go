@@ -0,0 +1,132 @@
+package provider
+
+import (
+	"os"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestEnv_Open(t *testing.T) {
+	t.Setenv("CLT_WELCOME__TITLE", "hi")
+	t.Setenv("CLT_WELCOME__MESSAGES", "[a, b]")
+	t.Setenv("CLT_SERVER__BIND", ":9090")
+	t.Setenv("CLT_SERVER__DEBUG", "true")
+	t.Setenv("OTHER_SERVER__BIND", ":1")
+	p := NewEnv("CLT_")
+	cs, err := p.Open()
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if len(cs) != 1 || cs[0].Group != "env" || cs[0].ID != "CLT_" {
+		t.Fatalf("bad content: %+v", cs)
+	}
+	var doc struct {
+		Welcome struct {
+			Title    string   `yaml:"title"`
+			Messages []string `yaml:"messages"`
+		} `yaml:"welcome"`
+		Server struct {
+			Bind  string `yaml:"bind"`
+			Debug bool   `yaml:"debug"`
+		} `yaml:"server"`
+	}
+	if err := yaml.Unmarshal([]byte(cs[0].Payload), &doc); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if doc.Welcome.Title != "hi" || len(doc.Welcome.Messages) != 2 || doc.Server.Bind != ":9090" || !doc.Server.Debug {
+		t.Fatalf("bad doc: %+v", doc)
+	}
+}
+
+func TestEnv_OpenEmpty(t *testing.T) {
+	p := NewEnv("CLT_NOTHING_SET_")
+	cs, err := p.Open()
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if len(cs) != 1 {
+		t.Fatalf("bad content: %+v", cs)
+	}
+}
+
+func TestEnv_OpenConflict(t *testing.T) {
+	p := &EnvProvider{Prefix: "X_", environ: func() []string {
+		return []string{"X_SERVER=1", "X_SERVER__BIND=:1"}
+	}}
+	if _, err := p.Open(); err == nil {
+		t.Fatalf("want error")
+	}
+}
+
+func TestEnv_EmptyPrefix(t *testing.T) {
+	p := &EnvProvider{environ: func() []string { return []string{"PATH=/bin", "A__B=1", "A=2"} }}
+	if _, err := p.Open(); err == nil {
+		t.Fatalf("want prefix error")
+	}
+}
+
+func TestPropertiesYAML(t *testing.T) {
+	got, err := propertiesYAML(map[string]string{"server.bind": ":9090", "server.debug": "true", "name": "demo"})
+	if err != nil || got != "name: demo\nserver:\n    bind: :9090\n    debug: true\n" {
+		t.Fatalf("got %q %v", got, err)
+	}
+	if _, err := propertiesYAML(map[string]string{"a": "1", "a.b": "2"}); err == nil {
+		t.Fatalf("want conflict error")
+	}
+}
+
+func TestMulti_OpenOrder(t *testing.T) {
+	tmp, err := os.CreateTemp(t.TempDir(), "f-*.yaml")
+	if err != nil {
+		t.Fatalf("tmp: %v", err)
+	}
+	defer tmp.Close()
+	_, _ = tmp.WriteString("server:\n  bind: ':1'\n")
+	t.Setenv("CLT_SERVER__BIND", ":2")
+	p := NewMulti(NewFile(tmp.Name()), NewEnv("CLT_"))
+	cs, err := p.Open()
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if len(cs) != 2 || cs[0].Group != "file" || cs[1].Group != "env" {
+		t.Fatalf("bad content: %+v", cs)
+	}
+}
+
+func TestMulti_WatchError(t *testing.T) {
+	p := NewMulti(NewEnv("CLT_"), NewFile("/not-exist-abc.yaml"))
+	if err := p.Watch(func() error { return nil }); err == nil {
+		t.Fatalf("want error")
+	}
+}
+
+// pushSource 通过 WatchContents 推送内容的子 Provider。
+type pushSource struct {
+	payload string
+	push    func([]Content) error
+}
+
+func (p *pushSource) Open() ([]Content, error) {
+	return []Content{{ID: "push", Payload: p.payload}}, nil
+}
+func (p *pushSource) Watch(func() error) error { return nil }
+func (p *pushSource) WatchContents(fn func([]Content) error) error {
+	p.push = fn
+	return nil
+}
+
+func TestMulti_WatchContentsWithoutOpen(t *testing.T) {
+	t.Setenv("CLT_SERVER__BIND", ":2")
+	push := &pushSource{payload: "a: 1\n"}
+	p := NewMulti(NewEnv("CLT_"), push)
+	var got []Content
+	if err := p.WatchContents(func(cs []Content) error { got = cs; return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	// 未调用 Open 时，第一次变更也携带其他来源的内容
+	_ = push.push([]Content{{ID: "push", Payload: "a: 2\n"}})
+	if len(got) != 2 || got[0].Group != "env" || got[1].Payload != "a: 2\n" {
+		t.Fatalf("contents: %+v", got)
+	}
+}
@@ -2,70 +2,439 @@ package provider
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
 	"time"
 
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
 // EtcdProvider 从 etcd 指定 key 读取配置，并订阅变更。
+// Prefix 为 true 时 Key 作为前缀，其下每个 key 作为一个 Content，按 key 排序后由调用方依次合并。
 type EtcdProvider struct {
-	Endpoints   []string
-	Key         string
-	Username    string
-	Password    string
-	DialTimeout time.Duration
+	Endpoints        []string
+	Key              string
+	Prefix           bool
+	Username         string
+	Password         string
+	DialTimeout      time.Duration
+	TLS              TLSOptions    // 设置 CA/证书后以 TLS（或双向 TLS）连接，证书可热更新
+	AtRevision       int64         // 大于 0 时读取该历史修订的配置，且不再监听变更
+	RetryInterval    time.Duration // 监听中断后的首次重试间隔，按倍数退避至 30s
+	ProgressInterval time.Duration // 请求进度通知的间隔，超过两个间隔无响应视为异常
+	Pool             *ClientPool   // 设置后从池中获取共享客户端
 
-	cli *clientv3.Client
+	mu       sync.Mutex
+	cli      *clientv3.Client
+	release  func() error // 释放自建或池中获取的客户端；外部注入的客户端为 nil
+	rev      int64
+	snapshot map[string]Content // 最近一次读取或事件之后的全部条目，按 key 索引
+	health   Health
+	cancel   context.CancelFunc
 }
 
 func NewEtcd(endpoints []string, key string, username, password string) *EtcdProvider {
-	return &EtcdProvider{Endpoints: endpoints, Key: key, Username: username, Password: password, DialTimeout: 5 * time.Second}
+	return &EtcdProvider{Endpoints: endpoints, Key: key, Username: username, Password: password, DialTimeout: 5 * time.Second, RetryInterval: time.Second, ProgressInterval: 30 * time.Second}
+}
+
+// NewEtcdWithClient 使用已有客户端创建 EtcdProvider，Close 时不会关闭该客户端。
+func NewEtcdWithClient(cli *clientv3.Client, key string) *EtcdProvider {
+	p := NewEtcd(nil, key, "", "")
+	p.cli = cli
+	return p
+}
+
+// NewEtcdPrefix 创建前缀模式的 EtcdProvider，读取并监听 prefix 下的全部 key。
+func NewEtcdPrefix(endpoints []string, prefix string, username, password string) *EtcdProvider {
+	p := NewEtcd(endpoints, prefix, username, password)
+	p.Prefix = true
+	return p
 }
 
 func (p *EtcdProvider) ensureClient() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	if p.cli != nil {
 		return nil
 	}
-	cfg := clientv3.Config{Endpoints: p.Endpoints, DialTimeout: p.DialTimeout}
-	if p.Username != "" || p.Password != "" {
-		cfg.Username = p.Username
-		cfg.Password = p.Password
+	o := EtcdClientOptions{Endpoints: p.Endpoints, Username: p.Username, Password: p.Password, DialTimeout: p.DialTimeout, TLS: p.TLS}
+	if p.Pool != nil {
+		cli, release, err := p.Pool.Etcd(o)
+		if err != nil {
+			return err
+		}
+		p.cli, p.release = cli, release
+		return nil
 	}
-	cli, err := clientv3.New(cfg)
+	cli, err := newEtcdClient(o)
 	if err != nil {
 		return err
 	}
-	p.cli = cli
+	p.cli, p.release = cli, cli.Close
 	return nil
 }
 
-func (p *EtcdProvider) Open() ([]Content, error) {
+func newEtcdClient(o EtcdClientOptions) (*clientv3.Client, error) {
+	cfg := clientv3.Config{Endpoints: o.Endpoints, DialTimeout: o.DialTimeout}
+	if o.Username != "" || o.Password != "" {
+		cfg.Username = o.Username
+		cfg.Password = o.Password
+	}
+	if o.TLS.Enabled() {
+		tlsCfg, err := etcdTLSConfig(o)
+		if err != nil {
+			return nil, err
+		}
+		cfg.TLS = tlsCfg
+	}
+	return clientv3.New(cfg)
+}
+
+// etcdTLSConfig 构建连接 etcd 的 TLS 配置。单个 endpoint 时以其主机名校验证书；多个 endpoint 共用一个配置，
+// 域名 endpoint 由各自握手的 SNI 校验，IP endpoint 没有 SNI，需要设置 ServerName。
+func etcdTLSConfig(o EtcdClientOptions) (*tls.Config, error) {
+	if len(o.Endpoints) == 1 {
+		return o.TLS.ConfigFor(tlsHost(o.Endpoints[0]))
+	}
+	if o.TLS.CAFile != "" && o.TLS.ServerName == "" && !o.TLS.InsecureSkipVerify {
+		for _, ep := range o.Endpoints {
+			if net.ParseIP(tlsHost(ep)) != nil {
+				return nil, fmt.Errorf("tls: etcd endpoint %s is an IP address, set a server name to verify it against", ep)
+			}
+		}
+	}
+	return o.TLS.Config()
+}
+
+// client 返回可用的客户端，必要时创建。
+func (p *EtcdProvider) client() (*clientv3.Client, error) {
 	if err := p.ensureClient(); err != nil {
 		return nil, err
 	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.cli, nil
+}
+
+func (p *EtcdProvider) Open() ([]Content, error) {
+	cli, err := p.client()
+	if err != nil {
+		return nil, err
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
-	resp, err := p.cli.Get(ctx, p.Key)
+	opts := p.keyOpts(clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+	if p.AtRevision > 0 {
+		opts = append(opts, clientv3.WithRev(p.AtRevision))
+	}
+	resp, err := cli.Get(ctx, p.Key, opts...)
 	if err != nil {
 		return nil, err
 	}
-	if len(resp.Kvs) == 0 {
-		return []Content{}, nil
+	out := make([]Content, 0, len(resp.Kvs))
+	snapshot := make(map[string]Content, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		c := Content{ID: string(kv.Key), Group: "etcd", Payload: string(kv.Value), Revision: kv.ModRevision, Version: kv.Version}
+		out = append(out, c)
+		snapshot[c.ID] = c
+	}
+	p.mu.Lock()
+	// 监听已推进到更新的修订时保留事件维护的快照
+	if resp.Header.Revision >= p.rev {
+		p.rev = resp.Header.Revision
+		p.snapshot = snapshot
 	}
-	payload := string(resp.Kvs[0].Value)
-	return []Content{{ID: p.Key, Group: "etcd", Payload: payload}}, nil
+	p.mu.Unlock()
+	return out, nil
 }
 
 func (p *EtcdProvider) Watch(onChange func() error) error {
-	if err := p.ensureClient(); err != nil {
+	return p.WatchContents(func([]Content) error { return onChange() })
+}
+
+// WatchContents 在后台持续监听，事件直接携带变更后的内容与修订，无需再次 Get。
+// 通道关闭（leader 丢失、连接中断等）后从最后确认的修订恢复；
+// 若所需历史已被压缩，则完整重新读取后再继续监听。
+func (p *EtcdProvider) WatchContents(onChange func([]Content) error) error {
+	cli, err := p.client()
+	if err != nil {
 		return err
 	}
-	go func() {
-		wch := p.cli.Watch(context.Background(), p.Key)
-		for range wch {
-			// 任意事件触发重新加载
-			_ = onChange()
-		}
-	}()
+	if p.AtRevision > 0 {
+		// 固定在历史修订，配置不随后续写入变化
+		p.mu.Lock()
+		p.health = Health{OK: true, Since: time.Now()}
+		p.mu.Unlock()
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p.mu.Lock()
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.cancel = cancel
+	p.health = Health{Err: errors.New("etcd watch starting"), Since: time.Now()}
+	p.mu.Unlock()
+	go p.watchLoop(ctx, cli, onChange)
 	return nil
 }
+
+func (p *EtcdProvider) watchLoop(ctx context.Context, cli *clientv3.Client, onChange func([]Content) error) {
+	retry := p.RetryInterval
+	if retry <= 0 {
+		retry = time.Second
+	}
+	progress := p.ProgressInterval
+	if progress <= 0 {
+		progress = 30 * time.Second
+	}
+	backoff := retry
+	wait := func() bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > 30*time.Second {
+			backoff = 30 * time.Second
+		}
+		return true
+	}
+	for {
+		if p.revision() == 0 {
+			// 尚未读取过：先建立快照，以便前缀模式下的事件能组装出完整内容
+			if _, err := p.Open(); err != nil {
+				p.setHealth(false, err)
+				if !wait() {
+					return
+				}
+				continue
+			}
+		}
+		opts := p.keyOpts(clientv3.WithCreatedNotify(), clientv3.WithProgressNotify())
+		if rev := p.revision(); rev > 0 {
+			opts = append(opts, clientv3.WithRev(rev+1))
+		}
+		wctx, wcancel := context.WithCancel(clientv3.WithRequireLeader(ctx))
+		wch := cli.Watch(wctx, p.Key, opts...)
+		ticker := time.NewTicker(progress)
+		last := time.Now()
+		var closeErr error
+		resynced := false
+	recv:
+		for {
+			select {
+			case resp, ok := <-wch:
+				if !ok {
+					break recv
+				}
+				if resp.CompactRevision != 0 {
+					// 需要的历史已被压缩，无法重放丢失的事件：完整重新读取后从最新修订继续
+					closeErr = resp.Err()
+					if cs, err := p.Open(); err == nil {
+						resynced = true
+						_ = onChange(cs)
+					}
+					break recv
+				}
+				if err := resp.Err(); err != nil {
+					closeErr = err
+					break recv
+				}
+				last = time.Now()
+				p.setHealth(true, nil)
+				backoff = retry
+				// 重放历史时响应头是当前修订，因此以事件自身的修订推进；进度通知保证此前事件均已送达
+				if resp.IsProgressNotify() {
+					p.observe(resp.Header.Revision)
+				}
+				if len(resp.Events) > 0 {
+					_ = onChange(p.applyEvents(resp.Events))
+				}
+			case <-ticker.C:
+				// 连接不可用时通道不会关闭，依靠进度通知判断监听是否仍然有效
+				if time.Since(last) > 2*progress {
+					p.setHealth(false, fmt.Errorf("no etcd watch response since %s", last.Format(time.RFC3339)))
+				}
+				_ = cli.RequestProgress(wctx)
+			}
+		}
+		ticker.Stop()
+		wcancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if resynced {
+			continue
+		}
+		if closeErr == nil {
+			closeErr = errors.New("etcd watch channel closed")
+		}
+		p.setHealth(false, closeErr)
+		if !wait() {
+			return
+		}
+	}
+}
+
+// Health 返回监听状态与最近确认的修订。
+func (p *EtcdProvider) Health() Health {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	h := p.health
+	h.Revision = p.rev
+	if p.AtRevision > 0 {
+		h.Revision = p.AtRevision
+	}
+	return h
+}
+
+// KeyRevision 描述 key 的一个历史版本。
+// etcd 不记录写入时间，因此只能按修订号定位；结合发布记录即可二分定位问题变更。
+type KeyRevision struct {
+	Key      string
+	Revision int64 // 该版本写入时的 ModRevision
+	Version  int64
+	Payload  string
+}
+
+// History 从 AtRevision（为 0 时从最新修订）开始向前列出 key 最多 limit 个历史版本，新版本在前。
+// key 为空时使用 p.Key；前缀模式下则按键名顺序列出该修订时前缀下每个 key 各自的历史，
+// 在此之前已删除的 key 不会列出。遇到 key 的首个版本（之前被删除或尚未创建）或已压缩的修订时停止。
+func (p *EtcdProvider) History(key string, limit int) ([]KeyRevision, error) {
+	cli, err := p.client()
+	if err != nil {
+		return nil, err
+	}
+	if key != "" || !p.Prefix {
+		if key == "" {
+			key = p.Key
+		}
+		return p.keyHistory(cli, key, limit)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	resp, err := cli.Get(ctx, p.Key, clientv3.WithPrefix(), clientv3.WithKeysOnly(), clientv3.WithRev(p.AtRevision),
+		clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	var out []KeyRevision
+	for _, kv := range resp.Kvs {
+		hs, err := p.keyHistory(cli, string(kv.Key), limit)
+		out = append(out, hs...)
+		if err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+func (p *EtcdProvider) keyHistory(cli *clientv3.Client, key string, limit int) ([]KeyRevision, error) {
+	var out []KeyRevision
+	rev := p.AtRevision
+	for limit <= 0 || len(out) < limit {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		resp, err := cli.Get(ctx, key, clientv3.WithRev(rev))
+		cancel()
+		if errors.Is(err, rpctypes.ErrCompacted) {
+			break
+		}
+		if err != nil {
+			return out, err
+		}
+		if len(resp.Kvs) == 0 {
+			break
+		}
+		kv := resp.Kvs[0]
+		out = append(out, KeyRevision{Key: key, Revision: kv.ModRevision, Version: kv.Version, Payload: string(kv.Value)})
+		if kv.Version <= 1 {
+			break
+		}
+		rev = kv.ModRevision - 1
+	}
+	return out, nil
+}
+
+// Close 停止监听，并关闭（或归还到池中）由本 Provider 获取的客户端。
+func (p *EtcdProvider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cancel != nil {
+		p.cancel()
+		p.cancel = nil
+	}
+	if p.release == nil {
+		return nil
+	}
+	err := p.release()
+	p.cli, p.release = nil, nil
+	return err
+}
+
+func (p *EtcdProvider) revision() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.rev
+}
+
+// applyEvents 将事件应用到快照，返回按 key 排序的全部内容；
+// 本批被删除的 key 以 Deleted 条目附在末尾，用于携带删除时的修订。
+func (p *EtcdProvider) applyEvents(events []*clientv3.Event) []Content {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.snapshot == nil {
+		p.snapshot = map[string]Content{}
+	}
+	var deleted []Content
+	for _, ev := range events {
+		key := string(ev.Kv.Key)
+		if ev.Type == clientv3.EventTypeDelete {
+			delete(p.snapshot, key)
+			deleted = append(deleted, Content{ID: key, Group: "etcd", Revision: ev.Kv.ModRevision, Deleted: true})
+		} else {
+			p.snapshot[key] = Content{ID: key, Group: "etcd", Payload: string(ev.Kv.Value), Revision: ev.Kv.ModRevision, Version: ev.Kv.Version}
+		}
+		if ev.Kv.ModRevision > p.rev {
+			p.rev = ev.Kv.ModRevision
+		}
+	}
+	out := make([]Content, 0, len(p.snapshot)+len(deleted))
+	for _, c := range p.snapshot {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return append(out, deleted...)
+}
+
+// observe 记录已确认的修订，只前进不后退。
+func (p *EtcdProvider) observe(rev int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if rev > p.rev {
+		p.rev = rev
+	}
+}
+
+func (p *EtcdProvider) setHealth(ok bool, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// 只在正常与异常之间切换时更新 Since，连续失败只更新错误
+	if p.health.OK != ok || p.health.Since.IsZero() {
+		p.health.Since = time.Now()
+	}
+	p.health.OK, p.health.Err = ok, err
+}
+
+// keyOpts 在前缀模式下追加 WithPrefix。
+func (p *EtcdProvider) keyOpts(opts ...clientv3.OpOption) []clientv3.OpOption {
+	if p.Prefix {
+		opts = append(opts, clientv3.WithPrefix())
+	}
+	return opts
+}
@@ -0,0 +1,339 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"config-loader/internal/etcdtest"
+)
+
+func TestEtcd_PrefixOpen(t *testing.T) {
+	s := etcdtest.New()
+	s.Put("/app/dir/b.yaml", "b: 2\n")
+	s.Put("/app/dir/a.yaml", "a: 1\n")
+	s.Put("/app/other", "x: 1\n")
+	p := &EtcdProvider{Key: "/app/dir/", Prefix: true, cli: s.Client()}
+	cs, err := p.Open()
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if len(cs) != 2 || cs[0].ID != "/app/dir/a.yaml" || cs[1].ID != "/app/dir/b.yaml" || cs[1].Payload != "b: 2\n" {
+		t.Fatalf("bad content: %+v", cs)
+	}
+}
+
+func TestEtcd_PrefixWatchAddRemove(t *testing.T) {
+	s := etcdtest.New()
+	s.Put("/app/dir/a.yaml", "a: 1\n")
+	p := &EtcdProvider{Key: "/app/dir/", Prefix: true, cli: s.Client()}
+	ch := make(chan struct{}, 4)
+	if err := p.Watch(func() error { ch <- struct{}{}; return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	waitEtcdWatchers(t, s, 1)
+	s.Put("/app/dir/b.yaml", "b: 2\n")
+	waitSignal(t, ch)
+	cs, err := p.Open()
+	if err != nil || len(cs) != 2 {
+		t.Fatalf("open after add: %v %+v", err, cs)
+	}
+	s.Delete("/app/dir/a.yaml")
+	waitSignal(t, ch)
+	cs, err = p.Open()
+	if err != nil || len(cs) != 1 || cs[0].ID != "/app/dir/b.yaml" {
+		t.Fatalf("open after delete: %v %+v", err, cs)
+	}
+	s.Put("/app/other", "x: 1\n")
+	select {
+	case <-ch:
+		t.Fatalf("unexpected change outside prefix")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func waitEtcdWatchers(t *testing.T, s *etcdtest.Server, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for s.Watchers() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("watchers: %d, want %d", s.Watchers(), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func waitSignal(t *testing.T, ch <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timeout")
+	}
+}
+
+func TestEtcd_EnsureClientTLSError(t *testing.T) {
+	p := NewEtcd([]string{"127.0.0.1:1"}, "/x", "", "")
+	p.TLS = TLSOptions{CAFile: "/not-exist-ca.pem"}
+	if err := p.ensureClient(); err == nil {
+		t.Fatalf("want error")
+	}
+}
+
+func TestEtcd_WatchResumeFromRevision(t *testing.T) {
+	s := etcdtest.New()
+	s.Put("/app/config", "a: 1\n")
+	p := &EtcdProvider{Key: "/app/config", cli: s.Client(), RetryInterval: 10 * time.Millisecond}
+	if _, err := p.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	// Open 与 Watch 之间的写入也不应丢失
+	s.Put("/app/config", "a: 2\n")
+	ch := make(chan struct{}, 4)
+	if err := p.Watch(func() error { ch <- struct{}{}; return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	defer p.Close()
+	waitSignal(t, ch)
+	waitEtcdWatchers(t, s, 1)
+	s.CloseWatches()
+	s.Put("/app/config", "a: 3\n")
+	waitSignal(t, ch)
+	if p.Health().Revision != s.Rev() {
+		t.Fatalf("bad revision: %d want %d", p.Health().Revision, s.Rev())
+	}
+}
+
+func TestEtcd_WatchCompacted(t *testing.T) {
+	s := etcdtest.New()
+	s.Put("/app/config", "a: 1\n")
+	p := &EtcdProvider{Key: "/app/config", cli: s.Client(), RetryInterval: 50 * time.Millisecond}
+	if _, err := p.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	ch := make(chan struct{}, 4)
+	if err := p.Watch(func() error { ch <- struct{}{}; return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	defer p.Close()
+	waitEtcdWatchers(t, s, 1)
+	s.CloseWatches()
+	s.Put("/app/config", "a: 2\n")
+	s.Put("/app/config", "a: 3\n")
+	s.Compact(s.Rev())
+	// 恢复时所需历史已被压缩：完整重新读取并通知
+	waitSignal(t, ch)
+	if p.Health().Revision != s.Rev() {
+		t.Fatalf("bad revision after resync: %d want %d", p.Health().Revision, s.Rev())
+	}
+	waitEtcdWatchers(t, s, 1)
+	s.Put("/app/config", "a: 4\n")
+	waitSignal(t, ch)
+}
+
+func TestEtcd_WatchHealth(t *testing.T) {
+	s := etcdtest.New()
+	p := &EtcdProvider{Key: "/app/config", cli: s.Client(), RetryInterval: time.Hour, ProgressInterval: 20 * time.Millisecond}
+	if err := p.Watch(func() error { return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	defer p.Close()
+	waitEtcdWatchers(t, s, 1)
+	waitHealth(t, p, true)
+	// 进度通知推进修订但不触发变更
+	s.Put("/app/other", "x: 1\n")
+	deadline := time.Now().Add(2 * time.Second)
+	for p.Health().Revision != s.Rev() {
+		if time.Now().After(deadline) {
+			t.Fatalf("progress not observed: %d want %d", p.Health().Revision, s.Rev())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	s.CloseWatches()
+	waitHealth(t, p, false)
+	if p.Health().Err == nil {
+		t.Fatalf("want error")
+	}
+}
+
+func TestEtcd_CloseStopsWatch(t *testing.T) {
+	s := etcdtest.New()
+	p := &EtcdProvider{Key: "/app/config", cli: s.Client()}
+	if err := p.Watch(func() error { return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	waitEtcdWatchers(t, s, 1)
+	if err := p.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	waitEtcdWatchers(t, s, 0)
+}
+
+func waitHealth(t *testing.T, p HealthReporter, ok bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for p.Health().OK != ok {
+		if time.Now().After(deadline) {
+			t.Fatalf("health: %+v, want ok=%v", p.Health(), ok)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestEtcd_HealthSinceOnTransition(t *testing.T) {
+	p := &EtcdProvider{}
+	p.setHealth(false, errors.New("dial 1"))
+	since := p.Health().Since
+	time.Sleep(5 * time.Millisecond)
+	p.setHealth(false, errors.New("dial 2"))
+	if h := p.Health(); !h.Since.Equal(since) || h.Err.Error() != "dial 2" {
+		t.Fatalf("repeated failure should keep since: %+v", h)
+	}
+	p.setHealth(true, nil)
+	if h := p.Health(); !h.OK || !h.Since.After(since) {
+		t.Fatalf("recovery should move since: %+v", h)
+	}
+}
+
+func TestEtcd_WatchContentsPayload(t *testing.T) {
+	s := etcdtest.New()
+	s.Put("/app/config", "a: 1\n")
+	p := &EtcdProvider{Key: "/app/config", cli: s.Client()}
+	cs, err := p.Open()
+	if err != nil || len(cs) != 1 || cs[0].Revision != s.Rev() || cs[0].Version != 1 {
+		t.Fatalf("open: %v %+v", err, cs)
+	}
+	ch := make(chan []Content, 4)
+	if err := p.WatchContents(func(cs []Content) error { ch <- cs; return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	defer p.Close()
+	waitEtcdWatchers(t, s, 1)
+	rev := s.Put("/app/config", "a: 2\n")
+	cs = waitContents(t, ch)
+	if len(cs) != 1 || cs[0].Payload != "a: 2\n" || cs[0].Revision != rev || cs[0].Version != 2 {
+		t.Fatalf("bad event content: %+v", cs)
+	}
+	rev = s.Delete("/app/config")
+	cs = waitContents(t, ch)
+	if len(cs) != 1 || !cs[0].Deleted || cs[0].Revision != rev {
+		t.Fatalf("bad delete content: %+v", cs)
+	}
+}
+
+func TestEtcd_WatchContentsPrefix(t *testing.T) {
+	s := etcdtest.New()
+	s.Put("/app/dir/a.yaml", "a: 1\n")
+	s.Put("/app/dir/b.yaml", "b: 1\n")
+	p := &EtcdProvider{Key: "/app/dir/", Prefix: true, cli: s.Client()}
+	ch := make(chan []Content, 4)
+	// 未先 Open 时由监听自行建立快照
+	if err := p.WatchContents(func(cs []Content) error { ch <- cs; return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	defer p.Close()
+	waitEtcdWatchers(t, s, 1)
+	s.Put("/app/dir/b.yaml", "b: 2\n")
+	cs := waitContents(t, ch)
+	if len(cs) != 2 || cs[0].Payload != "a: 1\n" || cs[1].Payload != "b: 2\n" {
+		t.Fatalf("bad event content: %+v", cs)
+	}
+	rev := s.Delete("/app/dir/a.yaml")
+	cs = waitContents(t, ch)
+	if len(cs) != 2 || cs[0].ID != "/app/dir/b.yaml" || !cs[1].Deleted || cs[1].Revision != rev {
+		t.Fatalf("bad delete content: %+v", cs)
+	}
+}
+
+func waitContents(t *testing.T, ch <-chan []Content) []Content {
+	t.Helper()
+	select {
+	case cs := <-ch:
+		return cs
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timeout")
+	}
+	return nil
+}
+
+func TestEtcd_OpenAtRevision(t *testing.T) {
+	s := etcdtest.New()
+	s.Put("/app/dir/a.yaml", "a: 1\n")
+	rev := s.Put("/app/dir/b.yaml", "b: 1\n")
+	s.Put("/app/dir/b.yaml", "b: 2\n")
+	s.Delete("/app/dir/a.yaml")
+	p := &EtcdProvider{Key: "/app/dir/", Prefix: true, AtRevision: rev, cli: s.Client()}
+	cs, err := p.Open()
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if len(cs) != 2 || cs[0].Payload != "a: 1\n" || cs[1].Payload != "b: 1\n" {
+		t.Fatalf("bad content: %+v", cs)
+	}
+	if err := p.Watch(func() error { return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	if s.Watchers() != 0 || !p.Health().OK || p.Health().Revision != rev {
+		t.Fatalf("pinned provider should not watch: %d %+v", s.Watchers(), p.Health())
+	}
+}
+
+func TestEtcd_History(t *testing.T) {
+	s := etcdtest.New()
+	s.Put("/app/config", "a: 0\n")
+	s.Delete("/app/config")
+	r1 := s.Put("/app/config", "a: 1\n")
+	s.Put("/app/other", "x: 1\n")
+	r2 := s.Put("/app/config", "a: 2\n")
+	r3 := s.Put("/app/config", "a: 3\n")
+	p := &EtcdProvider{Key: "/app/config", cli: s.Client()}
+	hs, err := p.History("", 0)
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	// 删除之前的版本属于上一代 key，不再列出
+	if len(hs) != 3 || hs[0].Revision != r3 || hs[1].Revision != r2 || hs[2].Revision != r1 || hs[2].Payload != "a: 1\n" || hs[0].Version != 3 {
+		t.Fatalf("bad history: %+v", hs)
+	}
+	hs, err = p.History("", 2)
+	if err != nil || len(hs) != 2 {
+		t.Fatalf("limited history: %v %+v", err, hs)
+	}
+	s.Compact(r2)
+	hs, err = p.History("", 0)
+	if err != nil || len(hs) != 2 {
+		t.Fatalf("compacted history: %v %+v", err, hs)
+	}
+}
+
+func TestEtcd_HistoryPrefix(t *testing.T) {
+	s := etcdtest.New()
+	a1 := s.Put("/app/dir/a", "a: 1\n")
+	b1 := s.Put("/app/dir/b", "b: 1\n")
+	a2 := s.Put("/app/dir/a", "a: 2\n")
+	s.Put("/app/dir/gone", "x: 1\n")
+	s.Delete("/app/dir/gone")
+	s.Put("/app/other", "x: 1\n")
+	p := &EtcdProvider{Key: "/app/dir/", Prefix: true, cli: s.Client()}
+	hs, err := p.History("", 0)
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	// 按键名顺序列出当前仍存在的每个 key 的历史，新版本在前
+	if len(hs) != 3 || hs[0].Key != "/app/dir/a" || hs[0].Revision != a2 || hs[1].Revision != a1 ||
+		hs[2].Key != "/app/dir/b" || hs[2].Revision != b1 {
+		t.Fatalf("bad history: %+v", hs)
+	}
+	// limit 按 key 计算；指定 key 时只列出该 key
+	if hs, err = p.History("", 1); err != nil || len(hs) != 2 || hs[0].Revision != a2 || hs[1].Revision != b1 {
+		t.Fatalf("limited history: %v %+v", err, hs)
+	}
+	if hs, err = p.History("/app/dir/b", 0); err != nil || len(hs) != 1 || hs[0].Key != "/app/dir/b" {
+		t.Fatalf("key history: %v %+v", err, hs)
+	}
+	// 历史修订时前缀下只有 a
+	p.AtRevision = a1
+	if hs, err = p.History("", 0); err != nil || len(hs) != 1 || hs[0].Revision != a1 {
+		t.Fatalf("history at revision: %v %+v", err, hs)
+	}
+}
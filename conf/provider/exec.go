@@ -0,0 +1,128 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// execWaitDelay 是命令退出或超时后等待其输出关闭的时间：命令留下的后台子进程可能一直持有标准输出。
+const execWaitDelay = time.Second
+
+// ExecProvider 运行外部命令并以其标准输出作为配置文档，
+// 适用于由 CLI 解密的配置（例如 sops -d secrets.yaml）。
+// Args 原样传给命令，不经过 shell 解析。
+type ExecProvider struct {
+	Command  string
+	Args     []string
+	Dir      string
+	Timeout  time.Duration
+	Interval time.Duration // 大于 0 时按周期重新执行
+
+	watcher
+	mu      sync.Mutex
+	sum     [sha256.Size]byte
+	pending *string // Watch 中取得、尚未被 Open 消费的输出
+}
+
+func NewExec(command string, args ...string) *ExecProvider {
+	return &ExecProvider{Command: command, Args: args, Timeout: 30 * time.Second}
+}
+
+func (p *ExecProvider) Open() ([]Content, error) {
+	p.mu.Lock()
+	pending := p.pending
+	p.pending = nil
+	p.mu.Unlock()
+	if pending != nil {
+		return []Content{p.content(*pending)}, nil
+	}
+	out, err := p.run()
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.sum = sha256.Sum256([]byte(out))
+	p.mu.Unlock()
+	return []Content{p.content(out)}, nil
+}
+
+// Watch 按 Interval 重新执行命令，仅当输出变化时通知。执行失败时保留当前配置，
+// 记录错误（含命令的标准错误输出）并标记为异常，退避后重试。
+func (p *ExecProvider) Watch(onChange func() error) error {
+	if p.Interval <= 0 {
+		return nil
+	}
+	p.startWatch(p.Interval, 0, func(context.Context) error {
+		out, err := p.run()
+		if err != nil {
+			slog.Error("exec re-run failed, keeping current config", "error", err)
+			return err
+		}
+		sum := sha256.Sum256([]byte(out))
+		p.mu.Lock()
+		changed := sum != p.sum
+		if changed {
+			p.sum = sum
+			p.pending = &out
+		}
+		p.mu.Unlock()
+		if changed {
+			_ = onChange()
+		}
+		return nil
+	})
+	return nil
+}
+
+// Close 停止周期执行。
+func (p *ExecProvider) Close() error {
+	p.stopWatch()
+	return nil
+}
+
+func (p *ExecProvider) run() (string, error) {
+	if p.Command == "" {
+		return "", errors.New("exec command is empty")
+	}
+	ctx := context.Background()
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, p.Command, p.Args...)
+	cmd.Dir = p.Dir
+	cmd.WaitDelay = execWaitDelay
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if errors.Is(err, exec.ErrWaitDelay) {
+		// 命令本身已成功退出，只是子进程仍持有输出，已读到的输出即为结果
+		slog.Warn("exec command left a process holding its output open", "command", p.Command)
+		err = nil
+	}
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timeout after %s", p.Timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("exec %s: %w: %s", p.Command, err, msg)
+		}
+		return "", fmt.Errorf("exec %s: %w", p.Command, err)
+	}
+	return stdout.String(), nil
+}
+
+func (p *ExecProvider) content(out string) Content {
+	id := strings.TrimSpace(strings.Join(append([]string{p.Command}, p.Args...), " "))
+	return Content{ID: id, Group: "exec", Payload: out}
+}
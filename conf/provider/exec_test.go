@@ -0,0 +1,134 @@
+//go:build !windows
+
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExec_Open(t *testing.T) {
+	p := NewExec("sh", "-c", "echo 'a: 1'")
+	cs, err := p.Open()
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if len(cs) != 1 || cs[0].Payload != "a: 1\n" || cs[0].Group != "exec" {
+		t.Fatalf("bad content: %+v", cs)
+	}
+}
+
+func TestExec_OpenStderr(t *testing.T) {
+	p := NewExec("sh", "-c", "echo 'decrypt failed' >&2; exit 3")
+	_, err := p.Open()
+	if err == nil || !strings.Contains(err.Error(), "decrypt failed") {
+		t.Fatalf("want stderr in error, got %v", err)
+	}
+}
+
+func TestExec_OpenTimeout(t *testing.T) {
+	p := NewExec("sleep", "5")
+	p.Timeout = 100 * time.Millisecond
+	_, err := p.Open()
+	if err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Fatalf("want timeout, got %v", err)
+	}
+}
+
+func TestExec_OpenBackgroundChild(t *testing.T) {
+	// 后台子进程继承了标准输出，不能让读取一直等到它退出
+	p := NewExec("sh", "-c", "echo 'a: 1'; sleep 10 &")
+	start := time.Now()
+	cs, err := p.Open()
+	if err != nil || len(cs) != 1 || cs[0].Payload != "a: 1\n" {
+		t.Fatalf("open: %v %+v", err, cs)
+	}
+	p = NewExec("sh", "-c", "sleep 10 & sleep 10")
+	p.Timeout = 100 * time.Millisecond
+	if _, err := p.Open(); err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Fatalf("want timeout, got %v", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Fatalf("blocked by child process for %s", d)
+	}
+}
+
+func TestExec_WatchOnlyOnChange(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.yaml")
+	if err := os.WriteFile(out, []byte("a: 1\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	p := NewExec("cat", out)
+	p.Interval = 50 * time.Millisecond
+	if _, err := p.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	ch := make(chan struct{}, 10)
+	if err := p.Watch(func() error { ch <- struct{}{}; return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	select {
+	case <-ch:
+		t.Fatalf("unexpected change")
+	case <-time.After(300 * time.Millisecond):
+	}
+	if err := os.WriteFile(out, []byte("a: 2\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	select {
+	case <-ch:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timeout")
+	}
+	cs, err := p.Open()
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if cs[0].Payload != "a: 2\n" {
+		t.Fatalf("bad payload: %q", cs[0].Payload)
+	}
+}
+
+func TestExec_WatchFailureAndClose(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.yaml")
+	runs := filepath.Join(dir, "runs")
+	if err := os.WriteFile(out, []byte("a: 1\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	p := NewExec("sh", "-c", "echo x >> "+runs+"; cat "+out)
+	p.Interval = 20 * time.Millisecond
+	if _, err := p.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := p.Watch(func() error { return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	if err := os.Remove(out); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for p.Health().OK {
+		if time.Now().After(deadline) {
+			t.Fatalf("failed re-run not reported")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if h := p.Health(); h.Err == nil || !strings.Contains(h.Err.Error(), "out.yaml") {
+		t.Fatalf("want stderr in health error, got %+v", h)
+	}
+
+	if err := p.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	before, _ := os.ReadFile(runs)
+	time.Sleep(200 * time.Millisecond)
+	after, _ := os.ReadFile(runs)
+	if len(after) != len(before) {
+		t.Fatalf("command still running after close")
+	}
+}
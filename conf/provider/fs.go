@@ -0,0 +1,204 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FSProvider 从任意 fs.FS（//go:embed 的内置默认配置、os.DirFS、zip/tar 包等）读取与 Patterns 匹配的文件，
+// 每个文件一个 Content：按模式顺序、同一模式内按路径顺序合并，被前面模式匹配过的文件不再重复。
+// 模式使用 path.Match 语法，另支持 "**" 匹配任意层目录与 "{a,b}" 多选。
+type FSProvider struct {
+	FS       fs.FS
+	Reload   func() (fs.FS, error) // 非空时每次读取前调用以取得最新的 FS，例如重新打开归档文件
+	Patterns []string              // 默认 **/*.{yaml,yml}，与 conf.LoadDir 一致
+
+	Interval      time.Duration // 轮询间隔，适用于内容会变化的 FS；0 表示不监听
+	RetryInterval time.Duration // 读取失败后首次重试的间隔，默认 1s
+
+	watcher
+	mu  sync.Mutex
+	sig string // 最近一次读取的路径与内容摘要
+}
+
+func NewFS(fsys fs.FS, patterns ...string) *FSProvider {
+	return &FSProvider{FS: fsys, Patterns: patterns, RetryInterval: time.Second}
+}
+
+// NewDirFS 读取本地目录，可配合 Interval 轮询。
+func NewDirFS(dir string, patterns ...string) *FSProvider {
+	return NewFS(os.DirFS(dir), patterns...)
+}
+
+// NewArchive 读取 zip、tar、tar.gz/tgz 归档，每次读取时重新打开，可配合 Interval 轮询归档的更新。
+func NewArchive(file string, patterns ...string) *FSProvider {
+	p := NewFS(nil, patterns...)
+	p.Reload = func() (fs.FS, error) { return OpenArchive(file) }
+	return p
+}
+
+func (p *FSProvider) patterns() []string {
+	if len(p.Patterns) == 0 {
+		return []string{"**/*.{yaml,yml}"}
+	}
+	return p.Patterns
+}
+
+func (p *FSProvider) Open() ([]Content, error) {
+	out, err := p.read()
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.sig = fsSignature(out)
+	p.mu.Unlock()
+	return out, nil
+}
+
+func (p *FSProvider) read() ([]Content, error) {
+	fsys := p.FS
+	if p.Reload != nil {
+		var err error
+		if fsys, err = p.Reload(); err != nil {
+			return nil, err
+		}
+	}
+	if fsys == nil {
+		return nil, errors.New("fs is nil")
+	}
+	var files []string
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var out []Content
+	for _, pattern := range p.patterns() {
+		alts := expandBraces(pattern)
+		for _, alt := range alts {
+			if _, err := path.Match(alt, ""); err != nil {
+				return nil, fmt.Errorf("fs pattern %q: %w", pattern, err)
+			}
+		}
+		for _, name := range files {
+			if seen[name] || !matchAny(alts, name) {
+				continue
+			}
+			seen[name] = true
+			b, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return nil, err
+			}
+			sum := sha256.Sum256(b)
+			out = append(out, Content{ID: name, Group: "fs", Payload: string(b), Meta: map[string]string{"sha256": fmt.Sprintf("%x", sum)}})
+		}
+	}
+	return out, nil
+}
+
+// expandBraces 展开 "{a,b}" 多选，不支持嵌套。
+func expandBraces(pattern string) []string {
+	i := strings.IndexByte(pattern, '{')
+	j := strings.IndexByte(pattern, '}')
+	if i < 0 || j < i {
+		return []string{pattern}
+	}
+	var out []string
+	for _, alt := range strings.Split(pattern[i+1:j], ",") {
+		out = append(out, expandBraces(pattern[:i]+alt+pattern[j+1:])...)
+	}
+	return out
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if matchGlob(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob 按 "/" 分段匹配，"**" 段匹配零到多层目录，其余段使用 path.Match。
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pat, name []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pat[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], name[0]); !ok {
+			return false
+		}
+		pat, name = pat[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// fsSignature 以路径与内容摘要标识一组文件。
+func fsSignature(contents []Content) string {
+	var b strings.Builder
+	for _, c := range contents {
+		fmt.Fprintf(&b, "%s@%s;", c.ID, c.Meta["sha256"])
+	}
+	return b.String()
+}
+
+func (p *FSProvider) Watch(onChange func() error) error {
+	return p.WatchContents(func([]Content) error { return onChange() })
+}
+
+// WatchContents 按 Interval 重新读取，有文件新增、删除或内容变化时携带全部内容通知。
+func (p *FSProvider) WatchContents(onChange func([]Content) error) error {
+	if p.Interval <= 0 {
+		return nil
+	}
+	p.startWatch(p.Interval, p.RetryInterval, func(context.Context) error {
+		out, err := p.read()
+		if err != nil {
+			return err
+		}
+		sig := fsSignature(out)
+		p.mu.Lock()
+		changed := sig != p.sig
+		p.sig = sig
+		p.mu.Unlock()
+		if changed {
+			_ = onChange(out)
+		}
+		return nil
+	})
+	return nil
+}
+
+// Close 停止轮询。
+func (p *FSProvider) Close() error {
+	p.stopWatch()
+	return nil
+}
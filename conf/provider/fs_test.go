@@ -0,0 +1,207 @@
+package provider
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestFS_PatternsOrder(t *testing.T) {
+	fsys := fstest.MapFS{
+		"defaults.yaml":        {Data: []byte("a: 1\n")},
+		"conf.d/20-db.yaml":    {Data: []byte("db: x\n")},
+		"conf.d/10-server.yml": {Data: []byte("server: y\n")},
+		"conf.d/nested/x.yaml": {Data: []byte("x: 1\n")},
+		"conf.d/README.md":     {Data: []byte("ignored\n")},
+		"overrides/local.yaml": {Data: []byte("a: 2\n")},
+	}
+	cs, err := NewFS(fsys, "defaults.yaml", "conf.d/*", "**/*.yaml").Open()
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	var ids []string
+	for _, c := range cs {
+		ids = append(ids, c.ID)
+	}
+	want := []string{"defaults.yaml", "conf.d/10-server.yml", "conf.d/20-db.yaml", "conf.d/README.md",
+		"conf.d/nested/x.yaml", "overrides/local.yaml"}
+	if len(ids) != len(want) {
+		t.Fatalf("ids: %v", ids)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("ids: %v", ids)
+		}
+	}
+
+	// 默认模式与 conf.LoadDir 一致
+	cs, err = NewFS(fsys).Open()
+	if err != nil || len(cs) != 5 || cs[0].ID != "conf.d/10-server.yml" || cs[1].ID != "conf.d/20-db.yaml" {
+		t.Fatalf("default patterns: %+v %v", cs, err)
+	}
+	if _, err := NewFS(fsys, "[").Open(); err == nil {
+		t.Fatalf("want bad pattern error")
+	}
+}
+
+func TestMatchPatterns(t *testing.T) {
+	cases := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"**/*.yaml", "a.yaml", true},
+		{"**/*.yaml", "a/b/c.yaml", true},
+		{"a/**/c.yaml", "a/c.yaml", true},
+		{"a/**/c.yaml", "a/x/y/c.yaml", true},
+		{"a/*.yaml", "a/b/c.yaml", false},
+		{"**", "a/b", true},
+		{"*.yaml", "a/b.yaml", false},
+		{"**/*.{yaml,yml}", "a/b.yml", true},
+		{"{conf,etc}/*.yaml", "etc/a.yaml", true},
+		{"{conf,etc}/*.yaml", "var/a.yaml", false},
+	}
+	for _, c := range cases {
+		if got := matchAny(expandBraces(c.pattern), c.name); got != c.want {
+			t.Fatalf("matchGlob(%q, %q) = %v", c.pattern, c.name, got)
+		}
+	}
+}
+
+func writeTarGz(t *testing.T, file string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, body := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(body)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(tw, body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestArchive_ZipAndTar(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{"bundle/app.yaml": "a: 1\n", "./bundle/conf/db.yaml": "db: x\n"}
+
+	tgz := filepath.Join(dir, "bundle.tgz")
+	writeTarGz(t, tgz, files)
+	fsys, err := OpenArchive(tgz)
+	if err != nil {
+		t.Fatalf("open tar: %v", err)
+	}
+	if err := fstest.TestFS(fsys, "bundle/app.yaml", "bundle/conf/db.yaml"); err != nil {
+		t.Fatalf("tar fs: %v", err)
+	}
+
+	zipFile := filepath.Join(dir, "bundle.zip")
+	f, err := os.Create(zipFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, body := range map[string]string{"bundle/app.yaml": "a: 1\n", "bundle/conf/db.yaml": "db: x\n"} {
+		w, _ := zw.Create(name)
+		_, _ = io.WriteString(w, body)
+	}
+	zw.Close()
+	f.Close()
+
+	for _, file := range []string{tgz, zipFile} {
+		cs, err := NewArchive(file, "bundle/*.yaml", "bundle/**/*.yaml").Open()
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		if len(cs) != 2 || cs[0].ID != "bundle/app.yaml" || cs[1].ID != "bundle/conf/db.yaml" || cs[1].Payload != "db: x\n" {
+			t.Fatalf("%s: %+v", file, cs)
+		}
+	}
+
+	if _, err := OpenArchive(filepath.Join(dir, "bundle.rar")); err == nil {
+		t.Fatalf("want unsupported format error")
+	}
+}
+
+func TestArchive_BadEntries(t *testing.T) {
+	dir := t.TempDir()
+	cases := map[string][]string{
+		"file-then-dir": {"a", "a/b.yaml"},
+		"dir-then-file": {"a/b.yaml", "a"},
+		"duplicate":     {"a.yaml", "a.yaml"},
+		"dotdot":        {"../a.yaml"},
+		"inner-dotdot":  {"a/../../a.yaml"},
+	}
+	for name, entries := range cases {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for _, e := range entries {
+			if err := tw.WriteHeader(&tar.Header{Name: e, Mode: 0o644, Size: 4, Typeflag: tar.TypeReg}); err != nil {
+				t.Fatal(err)
+			}
+			_, _ = io.WriteString(tw, "a: 1")
+		}
+		tw.Close()
+		file := filepath.Join(dir, name+".tar")
+		if err := os.WriteFile(file, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := OpenArchive(file); err == nil {
+			t.Fatalf("%s: want error", name)
+		}
+		if _, err := NewArchive(file, "**").Open(); err == nil {
+			t.Fatalf("%s: open: want error", name)
+		}
+	}
+}
+
+func TestArchive_WatchReplaced(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "bundle.tar.gz")
+	writeTarGz(t, file, map[string]string{"app.yaml": "v: 1\n"})
+
+	p := NewArchive(file)
+	p.Interval = 20 * time.Millisecond
+	defer p.Close()
+	if _, err := p.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	got := make(chan []Content, 4)
+	if err := p.WatchContents(func(cs []Content) error { got <- cs; return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	tmp := filepath.Join(dir, "next.tar.gz")
+	writeTarGz(t, tmp, map[string]string{"app.yaml": "v: 2\n"})
+	if err := os.Rename(tmp, file); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case cs := <-got:
+		if len(cs) != 1 || cs[0].Payload != "v: 2\n" {
+			t.Fatalf("contents: %+v", cs)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("no change")
+	}
+	if !p.Health().OK {
+		t.Fatalf("want healthy: %+v", p.Health())
+	}
+}
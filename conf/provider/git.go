@@ -0,0 +1,192 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GitProvider 通过 git 命令读取本地仓库中某个分支或标签下的文件或目录，按周期检查新的提交。
+// 目录按路径顺序合并其中的 yaml/yml/json 文件；提交的 SHA、作者与时间记录在 Content.Meta 中。
+// 提交之间没有单调递增的序号（提交时间可以任意设置，也可能因 rebase 回退），因此 Content.Revision 始终为 0。
+type GitProvider struct {
+	Repo    string // 本地仓库路径
+	Ref     string // 分支、标签或提交，默认 HEAD
+	Path    string // 仓库内的文件或目录，空表示仓库根目录
+	Remote  string // 非空时每次检查前先 git fetch 该远端的 Ref
+	Timeout time.Duration
+
+	Interval      time.Duration // 检查新提交的间隔，0 表示不监听
+	RetryInterval time.Duration // git 命令失败后首次重试的间隔，默认 1s
+
+	watcher
+	mu     sync.Mutex
+	commit string // 最近一次读取的提交
+}
+
+func NewGit(repo, ref, path string) *GitProvider {
+	return &GitProvider{Repo: repo, Ref: ref, Path: path, Timeout: 30 * time.Second, RetryInterval: time.Second}
+}
+
+func (p *GitProvider) ref() string {
+	if p.Ref == "" {
+		return "HEAD"
+	}
+	return p.Ref
+}
+
+// git 在仓库中执行 git 子命令并返回标准输出。
+func (p *GitProvider) git(ctx context.Context, args ...string) (string, error) {
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", p.Repo}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// resolve 返回 Ref 当前指向的提交；设置了 Remote 时先拉取。
+func (p *GitProvider) resolve(ctx context.Context) (string, error) {
+	if p.Repo == "" {
+		return "", errors.New("git repository is empty")
+	}
+	rev := p.ref()
+	if p.Remote != "" {
+		if _, err := p.git(ctx, "fetch", "--quiet", p.Remote, rev); err != nil {
+			return "", err
+		}
+		rev = "FETCH_HEAD"
+	}
+	out, err := p.git(ctx, "rev-parse", "--verify", "--end-of-options", rev+"^{commit}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (p *GitProvider) Open() ([]Content, error) {
+	ctx := context.Background()
+	commit, err := p.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out, err := p.read(ctx, commit)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.commit = commit
+	p.mu.Unlock()
+	return out, nil
+}
+
+// read 读取提交中 Path 指向的文件，或目录下的全部配置文件。
+func (p *GitProvider) read(ctx context.Context, commit string) ([]Content, error) {
+	info, err := p.git(ctx, "log", "-1", "--format=%an <%ae>%x00%cI", commit)
+	if err != nil {
+		return nil, err
+	}
+	author, committed, _ := strings.Cut(strings.TrimSpace(info), "\x00")
+
+	target := strings.Trim(p.Path, "/")
+	kind := "tree"
+	if target != "" {
+		out, err := p.git(ctx, "cat-file", "-t", commit+":"+target)
+		if err != nil {
+			return nil, fmt.Errorf("git path %s at %s: %w", target, p.ref(), err)
+		}
+		kind = strings.TrimSpace(out)
+	}
+	files := []string{target}
+	if kind == "tree" {
+		args := []string{"ls-tree", "-r", "-z", "--name-only", commit}
+		if target != "" {
+			args = append(args, "--", target)
+		}
+		out, err := p.git(ctx, args...)
+		if err != nil {
+			return nil, err
+		}
+		files = files[:0]
+		for _, f := range strings.Split(out, "\x00") {
+			switch path.Ext(f) {
+			case ".yaml", ".yml", ".json":
+				files = append(files, f)
+			}
+		}
+	}
+	meta := map[string]string{"commit": commit, "author": author, "time": committed, "ref": p.ref()}
+	out := make([]Content, 0, len(files))
+	for _, f := range files {
+		b, err := p.git(ctx, "cat-file", "blob", commit+":"+f)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[string]string, len(meta))
+		for k, v := range meta {
+			m[k] = v
+		}
+		out = append(out, Content{ID: f, Group: "git", Payload: b, Meta: m})
+	}
+	return out, nil
+}
+
+func (p *GitProvider) Watch(onChange func() error) error {
+	return p.WatchContents(func([]Content) error { return onChange() })
+}
+
+// WatchContents 按 Interval 检查 Ref 指向的提交，变化时携带新内容通知。
+func (p *GitProvider) WatchContents(onChange func([]Content) error) error {
+	if p.Interval <= 0 {
+		return nil
+	}
+	p.startWatch(p.Interval, p.RetryInterval, func(ctx context.Context) error {
+		changed, out, err := p.check(ctx)
+		if changed {
+			_ = onChange(out)
+		}
+		return err
+	})
+	return nil
+}
+
+// check 解析 Ref，提交变化时读取新内容。
+func (p *GitProvider) check(ctx context.Context) (bool, []Content, error) {
+	commit, err := p.resolve(ctx)
+	if err != nil {
+		return false, nil, err
+	}
+	p.mu.Lock()
+	last := p.commit
+	p.mu.Unlock()
+	if commit == last {
+		return false, nil, nil
+	}
+	out, err := p.read(ctx, commit)
+	if err != nil {
+		return false, nil, err
+	}
+	p.mu.Lock()
+	p.commit = commit
+	p.mu.Unlock()
+	return true, out, nil
+}
+
+// Close 停止检查。
+func (p *GitProvider) Close() error {
+	p.stopWatch()
+	return nil
+}
@@ -0,0 +1,152 @@
+package provider
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// gitRepo 在临时目录中初始化仓库，commit 写入文件并提交，返回提交的 SHA。
+type gitRepo struct {
+	t   *testing.T
+	dir string
+}
+
+func newGitRepo(t *testing.T) *gitRepo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	r := &gitRepo{t: t, dir: t.TempDir()}
+	r.run("init", "--quiet", "--initial-branch=main")
+	return r
+}
+
+func (r *gitRepo) run(args ...string) string {
+	r.t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", r.dir}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Alice", "GIT_AUTHOR_EMAIL=alice@example.com",
+		"GIT_COMMITTER_NAME=Alice", "GIT_COMMITTER_EMAIL=alice@example.com",
+		"GIT_CONFIG_NOSYSTEM=1", "HOME="+r.dir)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		r.t.Fatalf("git %v: %v: %s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func (r *gitRepo) commit(files map[string]string) string {
+	r.t.Helper()
+	for name, body := range files {
+		path := filepath.Join(r.dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			r.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			r.t.Fatal(err)
+		}
+	}
+	r.run("add", "-A")
+	r.run("commit", "--quiet", "-m", "update config")
+	return r.run("rev-parse", "HEAD")
+}
+
+func TestGit_OpenFileAndDir(t *testing.T) {
+	r := newGitRepo(t)
+	sha := r.commit(map[string]string{
+		"app.yaml":         "a: 1\n",
+		"conf/10-db.yaml":  "db: x\n",
+		"conf/00-base.yml": "base: true\n",
+		"conf/README.md":   "ignored\n",
+	})
+
+	cs, err := NewGit(r.dir, "main", "app.yaml").Open()
+	if err != nil {
+		t.Fatalf("open file: %v", err)
+	}
+	if len(cs) != 1 || cs[0].ID != "app.yaml" || cs[0].Payload != "a: 1\n" {
+		t.Fatalf("file contents: %+v", cs)
+	}
+	if cs[0].Meta["commit"] != sha || cs[0].Meta["author"] != "Alice <alice@example.com>" ||
+		cs[0].Meta["time"] == "" || cs[0].Revision != 0 {
+		t.Fatalf("meta: %+v rev=%d", cs[0].Meta, cs[0].Revision)
+	}
+
+	cs, err = NewGit(r.dir, "main", "conf").Open()
+	if err != nil {
+		t.Fatalf("open dir: %v", err)
+	}
+	if len(cs) != 2 || cs[0].ID != "conf/00-base.yml" || cs[1].ID != "conf/10-db.yaml" {
+		t.Fatalf("dir contents: %+v", cs)
+	}
+
+	if _, err := NewGit(r.dir, "main", "missing.yaml").Open(); err == nil {
+		t.Fatalf("want missing path error")
+	}
+	if _, err := NewGit(r.dir, "no-such-branch", "app.yaml").Open(); err == nil {
+		t.Fatalf("want unknown ref error")
+	}
+}
+
+func TestGit_PinnedTag(t *testing.T) {
+	r := newGitRepo(t)
+	r.commit(map[string]string{"app.yaml": "v: 1\n"})
+	r.run("tag", "v1")
+	r.commit(map[string]string{"app.yaml": "v: 2\n"})
+
+	cs, err := NewGit(r.dir, "v1", "app.yaml").Open()
+	if err != nil || cs[0].Payload != "v: 1\n" {
+		t.Fatalf("tag v1: %+v %v", cs, err)
+	}
+}
+
+func TestGit_WatchNewCommit(t *testing.T) {
+	r := newGitRepo(t)
+	r.commit(map[string]string{"app.yaml": "v: 1\n"})
+
+	p := NewGit(r.dir, "main", "app.yaml")
+	p.Interval = 20 * time.Millisecond
+	defer p.Close()
+	if _, err := p.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	got := make(chan []Content, 4)
+	if err := p.WatchContents(func(cs []Content) error { got <- cs; return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	sha := r.commit(map[string]string{"app.yaml": "v: 2\n"})
+	select {
+	case cs := <-got:
+		if cs[0].Payload != "v: 2\n" || cs[0].Meta["commit"] != sha {
+			t.Fatalf("contents: %+v", cs)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("no change")
+	}
+	if !p.Health().OK {
+		t.Fatalf("want healthy: %+v", p.Health())
+	}
+}
+
+func TestGit_FetchRemote(t *testing.T) {
+	upstream := newGitRepo(t)
+	upstream.commit(map[string]string{"app.yaml": "v: 1\n"})
+	clone := newGitRepo(t)
+	clone.run("remote", "add", "origin", upstream.dir)
+
+	p := NewGit(clone.dir, "main", "app.yaml")
+	p.Remote = "origin"
+	cs, err := p.Open()
+	if err != nil || cs[0].Payload != "v: 1\n" {
+		t.Fatalf("open: %+v %v", cs, err)
+	}
+	upstream.commit(map[string]string{"app.yaml": "v: 2\n"})
+	changed, cs, err := p.check(t.Context())
+	if err != nil || !changed || cs[0].Payload != "v: 2\n" {
+		t.Fatalf("check: %v %+v %v", changed, cs, err)
+	}
+}
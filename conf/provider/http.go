@@ -0,0 +1,182 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTPProvider 通过 HTTP(S) GET 读取配置文档，使用 ETag / Last-Modified 做条件请求，
+// 并按周期轮询或长轮询订阅变更。
+type HTTPProvider struct {
+	URL         string
+	Headers     map[string]string
+	BearerToken string // 支持 file:/path 与 env:NAME，见 ResolveSecret
+	TLS         TLSOptions
+	Timeout     time.Duration // 单次请求超时，默认 10s
+
+	Interval time.Duration // 轮询间隔，0 表示不轮询
+	// LongPoll 为 true 时携带 Prefer: wait=<秒> 发起条件请求，由服务端挂起直到配置变化或超时（304），
+	// 随后立即发起下一次请求；此时忽略 Interval。
+	LongPoll        bool
+	LongPollTimeout time.Duration // 期望服务端挂起的最长时间，默认 60s
+	RetryInterval   time.Duration // 请求失败后首次重试的间隔，默认 1s
+
+	watcher
+	mu           sync.Mutex
+	cli          *http.Client
+	etag         string
+	lastModified string
+	last         *Content
+}
+
+func NewHTTP(url string) *HTTPProvider {
+	return &HTTPProvider{URL: url, Timeout: 10 * time.Second, LongPollTimeout: 60 * time.Second, RetryInterval: time.Second}
+}
+
+func (p *HTTPProvider) client() (*http.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cli != nil {
+		return p.cli, nil
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	if p.TLS.Enabled() {
+		cfg, err := p.TLS.ConfigFor(tlsHost(p.URL))
+		if err != nil {
+			return nil, err
+		}
+		tr.TLSClientConfig = cfg
+	}
+	p.cli = &http.Client{Transport: tr}
+	return p.cli, nil
+}
+
+func (p *HTTPProvider) Open() ([]Content, error) {
+	c, _, err := p.fetch(context.Background(), 0)
+	if err != nil {
+		return nil, err
+	}
+	return []Content{c}, nil
+}
+
+// fetch 发起一次条件请求，wait 大于 0 时请求服务端挂起。返回当前内容及其是否变化。
+func (p *HTTPProvider) fetch(ctx context.Context, wait time.Duration) (Content, bool, error) {
+	if p.URL == "" {
+		return Content{}, false, errors.New("http url is empty")
+	}
+	cli, err := p.client()
+	if err != nil {
+		return Content{}, false, err
+	}
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout+wait)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return Content{}, false, err
+	}
+	for k, v := range p.Headers {
+		req.Header.Set(k, v)
+	}
+	if p.BearerToken != "" {
+		token, err := ResolveSecret(p.BearerToken)
+		if err != nil {
+			return Content{}, false, fmt.Errorf("http bearer token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	p.mu.Lock()
+	last := p.last
+	if last != nil {
+		if p.etag != "" {
+			req.Header.Set("If-None-Match", p.etag)
+		}
+		if p.lastModified != "" {
+			req.Header.Set("If-Modified-Since", p.lastModified)
+		}
+	}
+	p.mu.Unlock()
+	if wait > 0 {
+		req.Header.Set("Prefer", "wait="+strconv.Itoa(int(wait/time.Second)))
+	}
+	resp, err := cli.Do(req)
+	if err != nil {
+		return Content{}, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && last != nil {
+		return *last, false, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Content{}, false, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Content{}, false, fmt.Errorf("http get %s: %s: %s", p.URL, resp.Status, strings.TrimSpace(string(body)))
+	}
+	sum := sha256.Sum256(body)
+	c := Content{ID: p.URL, Group: "http", Payload: string(body), Meta: map[string]string{"sha256": fmt.Sprintf("%x", sum)}}
+	if v := resp.Header.Get("ETag"); v != "" {
+		c.Meta["etag"] = v
+	}
+	if v := resp.Header.Get("Last-Modified"); v != "" {
+		c.Meta["last-modified"] = v
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// 服务端不支持条件请求时按内容摘要判断是否变化
+	changed := p.last == nil || p.last.Meta["sha256"] != c.Meta["sha256"]
+	p.etag, p.lastModified, p.last = c.Meta["etag"], c.Meta["last-modified"], &c
+	return c, changed, nil
+}
+
+func (p *HTTPProvider) Watch(onChange func() error) error {
+	return p.WatchContents(func([]Content) error { return onChange() })
+}
+
+// WatchContents 在后台轮询或长轮询，内容变化时携带新内容通知。
+func (p *HTTPProvider) WatchContents(onChange func([]Content) error) error {
+	if p.Interval <= 0 && !p.LongPoll {
+		return nil
+	}
+	if _, err := p.client(); err != nil {
+		return err
+	}
+	interval, wait := p.Interval, time.Duration(0)
+	if p.LongPoll {
+		interval, wait = 0, p.LongPollTimeout
+		if wait <= 0 {
+			wait = 60 * time.Second
+		}
+	}
+	p.startWatch(interval, p.RetryInterval, func(ctx context.Context) error {
+		c, changed, err := p.fetch(ctx, wait)
+		if err == nil && changed {
+			_ = onChange([]Content{c})
+		}
+		return err
+	})
+	return nil
+}
+
+// Close 停止轮询。
+func (p *HTTPProvider) Close() error {
+	p.stopWatch()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cli != nil {
+		p.cli.CloseIdleConnections()
+	}
+	return nil
+}
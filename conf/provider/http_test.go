@@ -0,0 +1,200 @@
+package provider
+
+import (
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// configServer 模拟配置服务：支持 ETag 条件请求，Prefer: wait 时挂起直到版本变化。
+type configServer struct {
+	mu      sync.Mutex
+	version int
+	body    string
+	changed chan struct{}
+	gets    int
+	lastReq *http.Request
+}
+
+func newConfigServer(body string) *configServer {
+	return &configServer{version: 1, body: body, changed: make(chan struct{})}
+}
+
+func (s *configServer) set(body string) {
+	s.mu.Lock()
+	s.version++
+	s.body = body
+	close(s.changed)
+	s.changed = make(chan struct{})
+	s.mu.Unlock()
+}
+
+func (s *configServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.gets++
+	s.lastReq = r
+	etag := fmt.Sprintf(`"v%d"`, s.version)
+	changed := s.changed
+	s.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer tok" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if r.Header.Get("If-None-Match") == etag {
+		if r.Header.Get("Prefer") == "" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		select {
+		case <-changed:
+		case <-time.After(2 * time.Second):
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w.Header().Set("ETag", fmt.Sprintf(`"v%d"`, s.version))
+	_, _ = w.Write([]byte(s.body))
+}
+
+func TestHTTP_OpenConditional(t *testing.T) {
+	cs := newConfigServer("a: 1\n")
+	srv := httptest.NewServer(cs)
+	defer srv.Close()
+	p := NewHTTP(srv.URL)
+	p.BearerToken = "tok"
+	p.Headers = map[string]string{"X-App": "demo"}
+	for i := 0; i < 2; i++ {
+		got, err := p.Open()
+		if err != nil || len(got) != 1 || got[0].Payload != "a: 1\n" || got[0].Meta["etag"] != `"v1"` {
+			t.Fatalf("open %d: %+v %v", i, got, err)
+		}
+	}
+	if cs.lastReq.Header.Get("If-None-Match") != `"v1"` || cs.lastReq.Header.Get("X-App") != "demo" {
+		t.Fatalf("second request should be conditional: %v", cs.lastReq.Header)
+	}
+
+	p.BearerToken = "bad"
+	if _, err := p.Open(); err == nil {
+		t.Fatalf("want unauthorized error")
+	}
+}
+
+func TestHTTP_Poll(t *testing.T) {
+	cs := newConfigServer("a: 1\n")
+	srv := httptest.NewServer(cs)
+	defer srv.Close()
+	p := NewHTTP(srv.URL)
+	p.BearerToken = "tok"
+	p.Interval = 10 * time.Millisecond
+	defer p.Close()
+	if _, err := p.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	got := make(chan []Content, 4)
+	if err := p.WatchContents(func(c []Content) error { got <- c; return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	cs.set("a: 2\n")
+	select {
+	case c := <-got:
+		if c[0].Payload != "a: 2\n" {
+			t.Fatalf("payload: %q", c[0].Payload)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no change detected")
+	}
+	select {
+	case c := <-got:
+		t.Fatalf("unexpected notify: %+v", c)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if !p.Health().OK {
+		t.Fatalf("want healthy")
+	}
+}
+
+func TestHTTP_LongPoll(t *testing.T) {
+	cs := newConfigServer("a: 1\n")
+	srv := httptest.NewServer(cs)
+	defer srv.Close()
+	p := NewHTTP(srv.URL)
+	p.BearerToken = "tok"
+	p.LongPoll = true
+	p.LongPollTimeout = 2 * time.Second
+	defer p.Close()
+	if _, err := p.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	got := make(chan []Content, 1)
+	if err := p.WatchContents(func(c []Content) error { got <- c; return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	cs.set("a: 2\n")
+	select {
+	case c := <-got:
+		if c[0].Payload != "a: 2\n" {
+			t.Fatalf("payload: %q", c[0].Payload)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("long poll did not return on change")
+	}
+	cs.mu.Lock()
+	gets := cs.gets
+	cs.mu.Unlock()
+	if gets > 4 {
+		t.Fatalf("long poll should not spin, got %d requests", gets)
+	}
+}
+
+func TestHTTP_HealthOnError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	p := NewHTTP(srv.URL)
+	p.Interval = 10 * time.Millisecond
+	p.RetryInterval = 10 * time.Millisecond
+	defer p.Close()
+	if err := p.Watch(func() error { return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for p.Health().OK {
+		if time.Now().After(deadline) {
+			t.Fatalf("want unhealthy")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHTTP_TLS(t *testing.T) {
+	cs := newConfigServer("a: 1\n")
+	srv := httptest.NewTLSServer(cs)
+	defer srv.Close()
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(ca, pemBytes, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p := NewHTTP(srv.URL)
+	p.BearerToken = "tok"
+	if _, err := p.Open(); err == nil {
+		t.Fatalf("want unknown authority error")
+	}
+	p = NewHTTP(srv.URL)
+	p.BearerToken = "tok"
+	p.TLS = TLSOptions{CAFile: ca, ServerName: "example.com"}
+	if got, err := p.Open(); err != nil || got[0].Payload != "a: 1\n" {
+		t.Fatalf("open: %+v %v", got, err)
+	}
+}
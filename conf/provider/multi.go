@@ -0,0 +1,126 @@
+package provider
+
+import (
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// MultiProvider 按顺序组合多个 Provider，返回的内容依次拼接，
+// 解析时后面的文档覆盖前面的同名字段。
+type MultiProvider struct {
+	Providers []Provider
+
+	mu       sync.Mutex
+	last     [][]Content // 每个子 Provider 最近一次的内容，变更时与其余的重新拼接
+	notifyMu sync.Mutex  // 串行化 WatchContents 的通知，保证拼接结果按发生顺序送达
+}
+
+func NewMulti(providers ...Provider) *MultiProvider {
+	return &MultiProvider{Providers: providers}
+}
+
+func (p *MultiProvider) Open() ([]Content, error) {
+	last := make([][]Content, len(p.Providers))
+	for i, sub := range p.Providers {
+		cs, err := sub.Open()
+		if err != nil {
+			return nil, err
+		}
+		last[i] = cs
+	}
+	p.mu.Lock()
+	p.last = last
+	p.mu.Unlock()
+	return concatContents(last), nil
+}
+
+func concatContents(parts [][]Content) []Content {
+	var out []Content
+	for _, cs := range parts {
+		out = append(out, cs...)
+	}
+	return out
+}
+
+// Watch 订阅所有子 Provider，任意一个变更都会触发整体重新加载。
+func (p *MultiProvider) Watch(onChange func() error) error {
+	for _, sub := range p.Providers {
+		if err := sub.Watch(onChange); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WatchContents 订阅所有子 Provider：实现了 ContentWatcher 的子 Provider 直接使用事件携带的内容，
+// 其余的在变更后只重新 Open 该子 Provider；随后与其他子 Provider 最近一次的内容按顺序拼接后通知。
+// 尚未调用过 Open 时先读取一次全部子 Provider，避免第一次变更只携带单个来源的内容。
+func (p *MultiProvider) WatchContents(onChange func([]Content) error) error {
+	p.mu.Lock()
+	opened := len(p.last) == len(p.Providers)
+	p.mu.Unlock()
+	if !opened {
+		if _, err := p.Open(); err != nil {
+			return err
+		}
+	}
+	for i, sub := range p.Providers {
+		update := func(cs []Content) error {
+			p.notifyMu.Lock()
+			defer p.notifyMu.Unlock()
+			p.mu.Lock()
+			p.last[i] = cs
+			merged := concatContents(p.last)
+			p.mu.Unlock()
+			return onChange(merged)
+		}
+		var err error
+		if cw, ok := sub.(ContentWatcher); ok {
+			err = cw.WatchContents(update)
+		} else {
+			err = sub.Watch(func() error {
+				cs, err := sub.Open()
+				if err != nil {
+					// 保留该来源上一次的内容，等待下一次变更
+					slog.Error("reopen config source failed", "source", i, "error", err)
+					return err
+				}
+				return update(cs)
+			})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Health 汇总实现了 HealthReporter 的子 Provider，任意一个异常即视为异常。
+func (p *MultiProvider) Health() Health {
+	out := Health{OK: true}
+	for _, sub := range p.Providers {
+		hr, ok := sub.(HealthReporter)
+		if !ok {
+			continue
+		}
+		h := hr.Health()
+		if !h.OK && out.OK {
+			out = h
+		}
+	}
+	return out
+}
+
+// Close 关闭实现了 io.Closer 的子 Provider，返回遇到的第一个错误。
+func (p *MultiProvider) Close() error {
+	var first error
+	for _, sub := range p.Providers {
+		if c, ok := sub.(io.Closer); ok {
+			if err := c.Close(); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
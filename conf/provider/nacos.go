@@ -1,8 +1,17 @@
 package provider
 
 import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/nacos-group/nacos-sdk-go/v2/clients"
 	"github.com/nacos-group/nacos-sdk-go/v2/clients/config_client"
@@ -12,78 +21,463 @@ import (
 
 // NacosProvider 从 Nacos Config 服务读取配置，并订阅变更。
 type NacosProvider struct {
-	ServerAddrs []string // host:port
+	ServerAddrs []string // [http(s)://]host[:port][/contextPath]，端口默认 8848
 	NamespaceID string
 	Group       string
 	DataID      string
+	Configs     []NacosConfig // 共享/扩展配置，按顺序在 DataID 之前加载，后者覆盖前者
+	Pool        *ClientPool   // 设置后从池中获取共享客户端
 
-	timeoutMs uint64
+	// 鉴权与阿里云接入，凭据支持 file:/path 与 env:NAME 形式，见 ResolveSecret
+	Username  string
+	Password  string
+	AccessKey string
+	SecretKey string
+	Endpoint  string // 地址服务器，设置后可不填 ServerAddrs
+	RegionID  string
+	OpenKMS   bool // 使用 KMS 加解密 cipher- 前缀的配置
+
+	GrpcPortOffset uint64 // gRPC 端口相对 HTTP 端口的偏移，0 表示默认的 1000（经网关转发时需调整）
+
+	// 灰度与标签：AppName 随请求上报；Tag 非空时读取该标签的配置（不存在时回退正式版本）；
+	// DetectBeta 为 true 时记录是否命中 Beta 发布。二者都会改用 HTTP 开放接口读取并长轮询变更，
+	// 结果记录在 Content.Meta 的 beta 与 tag 中。
+	AppName    string
+	Tag        string
+	DetectBeta bool
+
+	// CacheDir 设置后每次成功读取都会写入快照；Failover 为 true 时服务端不可达则使用快照启动，
+	// 健康状态标记为异常，并按 RetryInterval 探测，恢复后切换回服务端的配置。
+	// RetryInterval 也是标签或灰度版本长轮询失败后首次重试的间隔。
+	CacheDir      string
+	Failover      bool
+	RetryInterval time.Duration
+
+	timeoutMs       uint64
+	longPollTimeout time.Duration // 标签或灰度版本长轮询的挂起时间，默认 30s
+	watcher
+	mu        sync.Mutex
 	cli       config_client.IConfigClient
+	release   func() error // 释放自建或池中获取的客户端；外部注入的客户端为 nil
+	listening []vo.ConfigParam
+	onChange  func([]Content) error
+	last      []Content // 最近一次读取或推送后的全部内容，按优先级排列
+	degraded  bool      // 正在使用快照
+	since     time.Time
+	lastErr   error
+	stop      chan struct{} // 关闭以停止恢复探测，nil 表示未在探测
+	token     nacosToken
+	servers   nacosServers // 从地址服务器获取的服务器列表
+}
+
+// NacosConfig 描述一个参与合并的 dataId。
+type NacosConfig struct {
+	DataID  string
+	Group   string // 为空时使用 Provider 的 Group
+	Refresh bool   // 是否订阅变更
+}
+
+// ParseNacosConfigs 解析逗号分隔的 dataId[@group][;refresh=false] 列表，refresh 默认开启。
+func ParseNacosConfigs(s string) ([]NacosConfig, error) {
+	var out []NacosConfig
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		c := NacosConfig{Refresh: true}
+		parts := strings.Split(item, ";")
+		c.DataID, c.Group, _ = strings.Cut(parts[0], "@")
+		if c.DataID == "" {
+			return nil, fmt.Errorf("nacos config %q: empty dataId", item)
+		}
+		for _, opt := range parts[1:] {
+			k, v, _ := strings.Cut(opt, "=")
+			if strings.TrimSpace(k) != "refresh" {
+				return nil, fmt.Errorf("nacos config %q: unknown option %q", item, k)
+			}
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("nacos config %q: bad refresh value %q", item, v)
+			}
+			c.Refresh = b
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// configs 返回按优先级从低到高排列的全部 dataId，DataID 最后加载且始终订阅。
+func (p *NacosProvider) configs() []NacosConfig {
+	out := make([]NacosConfig, 0, len(p.Configs)+1)
+	for _, c := range p.Configs {
+		if c.Group == "" {
+			c.Group = p.Group
+		}
+		out = append(out, c)
+	}
+	if p.DataID != "" {
+		out = append(out, NacosConfig{DataID: p.DataID, Group: p.Group, Refresh: true})
+	}
+	return out
 }
 
 func NewNacos(serverAddrs []string, namespaceID, group, dataID string) *NacosProvider {
-	return &NacosProvider{ServerAddrs: serverAddrs, NamespaceID: namespaceID, Group: group, DataID: dataID, timeoutMs: 3000}
+	return &NacosProvider{ServerAddrs: serverAddrs, NamespaceID: namespaceID, Group: group, DataID: dataID, timeoutMs: 3000, RetryInterval: 5 * time.Second}
+}
+
+// NewNacosWithClient 使用已有客户端创建 NacosProvider，Close 时只取消订阅，不会关闭该客户端。
+func NewNacosWithClient(cli config_client.IConfigClient, group, dataID string) *NacosProvider {
+	p := NewNacos(nil, "", group, dataID)
+	p.cli = cli
+	return p
 }
 
 func (p *NacosProvider) ensureClient() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	if p.cli != nil {
 		return nil
 	}
-	var sc []constant.ServerConfig
-	for _, addr := range p.ServerAddrs {
-		host, port := splitHostPort(addr)
-		sc = append(sc, *constant.NewServerConfig(host, port))
-	}
-	cc := constant.ClientConfig{
-		NamespaceId:         p.NamespaceID,
-		TimeoutMs:           p.timeoutMs,
-		NotLoadCacheAtStart: true,
+	o := p.clientOptions()
+	if p.Pool != nil {
+		c, release, err := p.Pool.Nacos(o)
+		if err != nil {
+			return err
+		}
+		p.cli, p.release = c, release
+		return nil
 	}
-	c, err := clients.NewConfigClient(vo.NacosClientParam{ClientConfig: &cc, ServerConfigs: sc})
+	c, err := newNacosClient(o)
 	if err != nil {
 		return err
 	}
 	p.cli = c
+	p.release = func() error { c.CloseClient(); return nil }
 	return nil
 }
 
-func (p *NacosProvider) Open() ([]Content, error) {
+func (p *NacosProvider) clientOptions() NacosClientOptions {
+	return NacosClientOptions{
+		ServerAddrs: p.ServerAddrs,
+		NamespaceID: p.NamespaceID,
+		TimeoutMs:   p.timeoutMs,
+		Username:    p.Username,
+		Password:    p.Password,
+		AccessKey:   p.AccessKey,
+		SecretKey:   p.SecretKey,
+		Endpoint:    p.Endpoint,
+		RegionID:    p.RegionID,
+		OpenKMS:     p.OpenKMS,
+		CacheDir:    p.CacheDir,
+
+		GrpcPortOffset: p.GrpcPortOffset,
+		AppName:        p.AppName,
+	}
+}
+
+// NacosClientOptions 描述 Nacos 连接参数，相同参数的 Provider 可共享客户端。
+type NacosClientOptions struct {
+	ServerAddrs []string
+	NamespaceID string
+	TimeoutMs   uint64
+	Username    string
+	Password    string
+	AccessKey   string
+	SecretKey   string
+	Endpoint    string
+	RegionID    string
+	OpenKMS     bool
+	CacheDir    string
+
+	GrpcPortOffset uint64 // gRPC 端口相对 HTTP 端口的偏移，0 表示默认的 1000
+	AppName        string
+}
+
+func newNacosClient(o NacosClientOptions) (config_client.IConfigClient, error) {
+	var sc []constant.ServerConfig
+	for _, addr := range o.ServerAddrs {
+		cfg, err := parseServerAddr(addr, o.GrpcPortOffset)
+		if err != nil {
+			return nil, err
+		}
+		sc = append(sc, cfg)
+	}
+	cc := constant.ClientConfig{
+		NamespaceId:         o.NamespaceID,
+		TimeoutMs:           o.TimeoutMs,
+		NotLoadCacheAtStart: true,
+		Endpoint:            o.Endpoint,
+		RegionId:            o.RegionID,
+		OpenKMS:             o.OpenKMS,
+		CacheDir:            o.CacheDir,
+		AppName:             o.AppName,
+		// 由 Provider 自行处理快照回退，以便感知并上报降级状态
+		DisableUseSnapShot: true,
+	}
+	secrets := []struct {
+		dst  *string
+		val  string
+		name string
+	}{
+		{&cc.Username, o.Username, "username"},
+		{&cc.Password, o.Password, "password"},
+		{&cc.AccessKey, o.AccessKey, "access key"},
+		{&cc.SecretKey, o.SecretKey, "secret key"},
+	}
+	for _, s := range secrets {
+		v, err := ResolveSecret(s.val)
+		if err != nil {
+			return nil, fmt.Errorf("nacos %s: %w", s.name, err)
+		}
+		*s.dst = v
+	}
+	return clients.NewConfigClient(vo.NacosClientParam{ClientConfig: &cc, ServerConfigs: sc})
+}
+
+// client 返回可用的客户端，必要时创建。
+func (p *NacosProvider) client() (config_client.IConfigClient, error) {
 	if err := p.ensureClient(); err != nil {
 		return nil, err
 	}
-	content, err := p.cli.GetConfig(vo.ConfigParam{DataId: p.DataID, Group: p.Group})
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.cli, nil
+}
+
+// Open 依次读取全部 dataId，返回的内容按优先级从低到高排列。
+// 开启 Failover 时，服务端不可达则改用快照，并在后台探测恢复。
+func (p *NacosProvider) Open() ([]Content, error) {
+	out, err := p.fetch()
+	if err == nil || !p.Failover || p.CacheDir == "" {
+		if err == nil {
+			p.markLive()
+		}
+		return out, err
+	}
+	out, serr := p.readSnapshots()
+	if serr != nil {
+		return nil, fmt.Errorf("%w (snapshot: %v)", err, serr)
+	}
+	p.setLast(out)
+	p.markDegraded(err)
+	return out, nil
+}
+
+// nacosContent 构造带 md5 的 Content。
+func nacosContent(dataID, group, payload string) Content {
+	sum := md5.Sum([]byte(payload))
+	return Content{ID: dataID, Group: group, Payload: payload, Meta: map[string]string{"md5": hex.EncodeToString(sum[:])}}
+}
+
+func (p *NacosProvider) setLast(contents []Content) {
+	p.mu.Lock()
+	p.last = append([]Content(nil), contents...)
+	p.mu.Unlock()
+}
+
+// applyPush 用推送的内容替换对应条目，md5 与当前一致时返回 false。
+func (p *NacosProvider) applyPush(c Content) ([]Content, bool) {
+	dataID, group := c.ID, c.Group
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, old := range p.last {
+		if old.ID != dataID || old.Group != group {
+			continue
+		}
+		if old.Meta["md5"] == c.Meta["md5"] && old.Meta["beta"] == c.Meta["beta"] && old.Meta["tag"] == c.Meta["tag"] {
+			return nil, false
+		}
+		p.last[i] = c
+		return append([]Content(nil), p.last...), true
+	}
+	// 尚未读取过的 dataId：按配置顺序插入
+	var out []Content
+	for _, cfg := range p.configs() {
+		if cfg.DataID == dataID && cfg.Group == group {
+			out = append(out, c)
+			continue
+		}
+		for _, old := range p.last {
+			if old.ID == cfg.DataID && old.Group == cfg.Group {
+				out = append(out, old)
+			}
+		}
+	}
+	p.last = out
+	return append([]Content(nil), out...), true
+}
+
+// fetch 从服务端读取全部 dataId，成功后写入快照。
+func (p *NacosProvider) fetch() ([]Content, error) {
+	cli, err := p.client()
 	if err != nil {
 		return nil, err
 	}
-	return []Content{{ID: p.DataID, Group: p.Group, Payload: content}}, nil
+	var out []Content
+	for _, c := range p.configs() {
+		content, err := p.get(cli, c)
+		if err != nil {
+			return nil, fmt.Errorf("nacos get %s@%s: %w", c.DataID, c.Group, err)
+		}
+		out = append(out, content)
+	}
+	if p.CacheDir != "" {
+		p.writeSnapshots(out)
+	}
+	p.setLast(out)
+	return out, nil
+}
+
+// get 读取单个 dataId。设置了 Tag 或 DetectBeta 时改用 HTTP 开放接口，以获得标签或灰度版本。
+func (p *NacosProvider) get(cli config_client.IConfigClient, c NacosConfig) (Content, error) {
+	if p.variantAware() {
+		return p.httpGet(c)
+	}
+	content, err := cli.GetConfig(vo.ConfigParam{DataId: c.DataID, Group: c.Group, AppName: p.AppName})
+	if err != nil {
+		return Content{}, err
+	}
+	return nacosContent(c.DataID, c.Group, content), nil
 }
 
 func (p *NacosProvider) Watch(onChange func() error) error {
-	if err := p.ensureClient(); err != nil {
+	return p.WatchContents(func([]Content) error { return onChange() })
+}
+
+// WatchContents 订阅所有 Refresh 为 true 的 dataId。推送的数据直接替换对应条目，
+// 与其余 dataId 按优先级一起交给 onChange；md5 未变化的重复推送会被忽略。
+// SDK 只监听正式版本，设置了 Tag 或 DetectBeta 时改为通过 HTTP 开放接口长轮询本实例实际读取的版本。
+func (p *NacosProvider) WatchContents(onChange func([]Content) error) error {
+	cli, err := p.client()
+	if err != nil {
 		return err
 	}
-	// 使用 ListenConfig 订阅变更
-	err := p.cli.ListenConfig(vo.ConfigParam{
-		DataId: p.DataID,
-		Group:  p.Group,
-		OnChange: func(namespace, group, dataId, data string) {
-			_ = onChange()
-		},
-	})
+	p.mu.Lock()
+	p.onChange = onChange
+	p.mu.Unlock()
+	if p.variantAware() {
+		p.startWatch(0, p.RetryInterval, func(ctx context.Context) error { return p.listenVariants(ctx, onChange) })
+		return nil
+	}
+	for _, c := range p.configs() {
+		if !c.Refresh {
+			continue
+		}
+		// 使用 ListenConfig 订阅变更
+		param := vo.ConfigParam{DataId: c.DataID, Group: c.Group}
+		listen := param
+		listen.OnChange = func(namespace, group, dataId, data string) {
+			p.push(nacosContent(dataId, group, data), onChange)
+		}
+		if err := cli.ListenConfig(listen); err != nil {
+			return err
+		}
+		p.mu.Lock()
+		p.listening = append(p.listening, param)
+		p.mu.Unlock()
+	}
 	// SDK 内部维护长连接，不需要主动循环
+	return nil
+}
+
+// push 用推送或重新读取的内容替换对应条目，有变化时写入快照并通知。
+func (p *NacosProvider) push(c Content, onChange func([]Content) error) {
+	contents, changed := p.applyPush(c)
+	if !changed {
+		return
+	}
+	if p.CacheDir != "" {
+		p.writeSnapshots([]Content{c})
+	}
+	_ = onChange(contents)
+}
+
+// Close 取消订阅，并关闭（或归还到池中）由本 Provider 获取的客户端。
+// 共享客户端上其他 Provider 的订阅不受影响。
+func (p *NacosProvider) Close() error {
+	p.stopWatch()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+	}
+	if p.cli == nil {
+		return nil
+	}
+	var err error
+	for _, param := range p.listening {
+		if cerr := p.cli.CancelListenConfig(param); err == nil {
+			err = cerr
+		}
+	}
+	p.listening = nil
+	if p.release != nil {
+		if rerr := p.release(); err == nil {
+			err = rerr
+		}
+		p.cli, p.release = nil, nil
+	}
 	return err
 }
 
-// splitHostPort parses "host:port" into host string and port uint64.
-func splitHostPort(addr string) (string, uint64) {
+// parseServerAddr 将 [scheme://]host[:port][/contextPath] 解析为 ServerConfig。
+// 端口默认 8848，上下文路径默认 /nacos，gRPC 端口为 HTTP 端口加 grpcOffset（0 表示默认的 1000）；
+// IPv6 地址可以带或不带方括号，不带时不能指定端口。
+func parseServerAddr(addr string, grpcOffset uint64) (constant.ServerConfig, error) {
 	s := strings.TrimSpace(addr)
 	if s == "" {
-		return "", 0
+		return constant.ServerConfig{}, errors.New("empty nacos server address")
+	}
+	scheme, rest, ok := strings.Cut(s, "://")
+	if !ok {
+		scheme, rest = "http", s
+	}
+	if scheme != "http" && scheme != "https" {
+		return constant.ServerConfig{}, fmt.Errorf("nacos server %q: unsupported scheme %q", addr, scheme)
+	}
+	host, path, _ := strings.Cut(rest, "/")
+	// 未加方括号的 IPv6 地址
+	if strings.Count(host, ":") > 1 && !strings.HasPrefix(host, "[") {
+		if net.ParseIP(host) == nil {
+			return constant.ServerConfig{}, fmt.Errorf("nacos server %q: invalid host %q", addr, host)
+		}
+		host = "[" + host + "]"
+	}
+	u, err := url.Parse(scheme + "://" + host + "/" + path)
+	if err != nil {
+		return constant.ServerConfig{}, fmt.Errorf("nacos server %q: %w", addr, err)
+	}
+	if u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return constant.ServerConfig{}, fmt.Errorf("nacos server %q: unexpected userinfo, query or fragment", addr)
+	}
+	ip := u.Hostname()
+	if ip == "" {
+		return constant.ServerConfig{}, fmt.Errorf("nacos server %q: missing host", addr)
+	}
+	if strings.Contains(ip, ":") {
+		// SDK 以 ip + ":" + port 拼接地址，IPv6 需要保留方括号
+		ip = "[" + ip + "]"
+	}
+	port := uint64(8848)
+	if ps := u.Port(); ps != "" {
+		port, err = strconv.ParseUint(ps, 10, 16)
+		if err != nil || port == 0 {
+			return constant.ServerConfig{}, fmt.Errorf("nacos server %q: invalid port %q", addr, ps)
+		}
+	} else if strings.HasSuffix(u.Host, ":") {
+		return constant.ServerConfig{}, fmt.Errorf("nacos server %q: empty port", addr)
+	}
+	if grpcOffset == 0 {
+		grpcOffset = constant.RpcPortOffset
+	}
+	if port+grpcOffset > 65535 {
+		return constant.ServerConfig{}, fmt.Errorf("nacos server %q: grpc port %d out of range", addr, port+grpcOffset)
 	}
-	parts := strings.Split(s, ":")
-	if len(parts) != 2 {
-		return s, 0
+	contextPath := strings.TrimSuffix(u.Path, "/")
+	if contextPath == "" {
+		contextPath = constant.DEFAULT_CONTEXT_PATH
 	}
-	p, _ := strconv.ParseUint(parts[1], 10, 64)
-	return parts[0], p
+	return constant.ServerConfig{Scheme: scheme, ContextPath: contextPath, IpAddr: ip, Port: port, GrpcPort: port + grpcOffset}, nil
 }
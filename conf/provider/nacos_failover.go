@@ -0,0 +1,121 @@
+package provider
+
+import (
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// snapshotPath 返回 dataId 的快照路径：<CacheDir>/snapshot/<namespace>/<group>/<dataId>。
+func (p *NacosProvider) snapshotPath(c NacosConfig) string {
+	ns := p.NamespaceID
+	if ns == "" {
+		ns = "public"
+	}
+	return filepath.Join(p.CacheDir, "snapshot", url.PathEscape(ns), url.PathEscape(c.Group), url.PathEscape(c.DataID))
+}
+
+// writeSnapshots 先写临时文件再改名，避免进程中断留下不完整的快照；失败只记录日志。
+func (p *NacosProvider) writeSnapshots(contents []Content) {
+	for _, c := range contents {
+		path := p.snapshotPath(NacosConfig{DataID: c.ID, Group: c.Group})
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			slog.Warn("write nacos snapshot failed", "dataId", c.ID, "error", err)
+			continue
+		}
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, []byte(c.Payload), 0o600); err != nil {
+			slog.Warn("write nacos snapshot failed", "dataId", c.ID, "error", err)
+			continue
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			slog.Warn("write nacos snapshot failed", "dataId", c.ID, "error", err)
+		}
+	}
+}
+
+func (p *NacosProvider) readSnapshots() ([]Content, error) {
+	var out []Content
+	for _, c := range p.configs() {
+		b, err := os.ReadFile(p.snapshotPath(c))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, nacosContent(c.DataID, c.Group, string(b)))
+	}
+	return out, nil
+}
+
+// markDegraded 标记正在使用快照，并启动恢复探测。
+func (p *NacosProvider) markDegraded(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.degraded {
+		p.degraded, p.since = true, time.Now()
+		slog.Warn("nacos unreachable, serving config from snapshot", "dir", p.CacheDir, "error", err)
+	}
+	p.lastErr = err
+	if p.stop == nil {
+		p.stop = make(chan struct{})
+		go p.recoverLoop(p.stop)
+	}
+}
+
+func (p *NacosProvider) markLive() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.degraded {
+		p.degraded, p.since, p.lastErr = false, time.Now(), nil
+	}
+}
+
+// recoverLoop 定期探测服务端，恢复后触发一次重新加载以切换到服务端的配置。
+func (p *NacosProvider) recoverLoop(stop chan struct{}) {
+	interval := p.RetryInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+		}
+		contents, err := p.fetch()
+		if err != nil {
+			p.mu.Lock()
+			p.lastErr = err
+			p.mu.Unlock()
+			continue
+		}
+		p.mu.Lock()
+		onChange := p.onChange
+		if p.stop == stop {
+			p.stop = nil
+		}
+		p.mu.Unlock()
+		p.markLive()
+		slog.Info("nacos reachable again, switching to live config")
+		if onChange != nil {
+			_ = onChange(contents)
+		}
+		return
+	}
+}
+
+// Health 在使用快照期间返回异常及最近一次探测失败的原因；标签或灰度版本的长轮询失败时同样返回异常。
+func (p *NacosProvider) Health() Health {
+	if h := p.watcher.Health(); !h.OK {
+		return h
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.degraded {
+		return Health{OK: false, Err: p.lastErr, Since: p.since}
+	}
+	return Health{OK: true, Since: p.since}
+}
@@ -0,0 +1,358 @@
+package provider
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// nacosToken 缓存 /v1/auth/login 返回的 accessToken。
+type nacosToken struct {
+	value   string
+	expires time.Time
+}
+
+// nacosServers 缓存地址服务器返回的服务器列表。
+type nacosServers struct {
+	addrs   []string
+	expires time.Time
+}
+
+// variantAware 判断是否需要通过 HTTP 开放接口读取：SDK 的 GetConfig 不支持 tag，也不返回是否命中 Beta。
+func (p *NacosProvider) variantAware() bool {
+	return p.Tag != "" || p.DetectBeta
+}
+
+func (p *NacosProvider) httpClient() *http.Client {
+	return &http.Client{Timeout: time.Duration(p.timeoutMs) * time.Millisecond}
+}
+
+// httpBases 返回各服务器开放接口的根地址：优先使用 ServerAddrs，否则从地址服务器（Endpoint）获取。
+func (p *NacosProvider) httpBases() ([]string, error) {
+	addrs := p.ServerAddrs
+	if len(addrs) == 0 {
+		if p.Endpoint == "" {
+			return nil, errors.New("nacos tag/beta reads require server addresses or an endpoint")
+		}
+		var err error
+		if addrs, err = p.endpointServers(); err != nil {
+			return nil, err
+		}
+	}
+	bases := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		sc, err := parseServerAddr(addr, p.GrpcPortOffset)
+		if err != nil {
+			return nil, err
+		}
+		bases = append(bases, fmt.Sprintf("%s://%s:%d%s", sc.Scheme, sc.IpAddr, sc.Port, sc.ContextPath))
+	}
+	return bases, nil
+}
+
+// endpointServers 与 SDK 一样从 http://<Endpoint>/nacos/serverlist 获取服务器列表（每行 host[:port]），缓存 30s。
+func (p *NacosProvider) endpointServers() ([]string, error) {
+	p.mu.Lock()
+	cached := p.servers
+	p.mu.Unlock()
+	if len(cached.addrs) > 0 && time.Now().Before(cached.expires) {
+		return cached.addrs, nil
+	}
+	resp, err := p.httpClient().Get("http://" + p.Endpoint + "/nacos/serverlist")
+	if err != nil {
+		return nil, fmt.Errorf("nacos endpoint %s: %w", p.Endpoint, err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("nacos endpoint %s: %w", p.Endpoint, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("nacos endpoint %s: %s", p.Endpoint, resp.Status)
+	}
+	var addrs []string
+	for _, line := range strings.Split(string(body), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			addrs = append(addrs, line)
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("nacos endpoint %s returned no servers", p.Endpoint)
+	}
+	p.mu.Lock()
+	p.servers = nacosServers{addrs: addrs, expires: time.Now().Add(30 * time.Second)}
+	p.mu.Unlock()
+	return addrs, nil
+}
+
+// newRequest 构造开放接口请求：带上登录令牌、AppName，并在设置了 AccessKey 时签名。
+func (p *NacosProvider) newRequest(ctx context.Context, method, base, path string, q url.Values, body io.Reader, group string) (*http.Request, error) {
+	token, err := p.accessToken(base)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		q.Set("accessToken", token)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, base+path+"?"+q.Encode(), body)
+	if err != nil {
+		return nil, err
+	}
+	if p.AppName != "" {
+		req.Header.Set("Client-AppName", p.AppName)
+	}
+	if err := p.sign(req, group); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// sign 按 SDK 的配置接口规则签名：Spas-Signature 为 SecretKey 对 "[namespace+]group+timestamp" 的 HMAC-SHA1。
+func (p *NacosProvider) sign(req *http.Request, group string) error {
+	if p.AccessKey == "" {
+		return nil
+	}
+	ak, err := ResolveSecret(p.AccessKey)
+	if err != nil {
+		return fmt.Errorf("nacos access key: %w", err)
+	}
+	sk, err := ResolveSecret(p.SecretKey)
+	if err != nil {
+		return fmt.Errorf("nacos secret key: %w", err)
+	}
+	ts := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	req.Header.Set("Spas-AccessKey", ak)
+	req.Header.Set("Timestamp", ts)
+	req.Header.Set("Spas-Signature", nacosSignature(sk, p.NamespaceID, group, ts))
+	return nil
+}
+
+func nacosSignature(secretKey, namespace, group, ts string) string {
+	resource := group
+	if namespace != "" {
+		resource = namespace + "+" + group
+	}
+	data := ts
+	if strings.TrimSpace(resource) != "" {
+		data = resource + "+" + ts
+	}
+	mac := hmac.New(sha1.New, []byte(secretKey))
+	mac.Write([]byte(data))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// httpGet 依次尝试各服务器读取 dataId；Tag 对应的配置不存在时回退到正式版本。
+func (p *NacosProvider) httpGet(c NacosConfig) (Content, error) {
+	bases, err := p.httpBases()
+	if err != nil {
+		return Content{}, err
+	}
+	var lastErr error
+	for _, base := range bases {
+		out, err := p.httpGetFrom(base, c)
+		if err == nil {
+			return out, nil
+		}
+		lastErr = err
+	}
+	return Content{}, lastErr
+}
+
+func (p *NacosProvider) httpGetFrom(base string, c NacosConfig) (Content, error) {
+	tags := []string{""}
+	if p.Tag != "" {
+		tags = []string{p.Tag, ""}
+	}
+	for _, tag := range tags {
+		q := url.Values{"dataId": {c.DataID}, "group": {c.Group}}
+		if p.NamespaceID != "" {
+			q.Set("tenant", p.NamespaceID)
+		}
+		if tag != "" {
+			q.Set("tag", tag)
+		}
+		req, err := p.newRequest(context.Background(), http.MethodGet, base, "/v1/cs/configs", q, nil, c.Group)
+		if err != nil {
+			return Content{}, err
+		}
+		resp, err := p.httpClient().Do(req)
+		if err != nil {
+			return Content{}, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return Content{}, err
+		}
+		if resp.StatusCode == http.StatusNotFound && tag != "" {
+			continue
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			// 与 SDK 一致：不存在的配置视为空内容
+			return nacosContent(c.DataID, c.Group, ""), nil
+		}
+		if resp.StatusCode != http.StatusOK {
+			return Content{}, fmt.Errorf("nacos %s: %s: %s", base, resp.Status, strings.TrimSpace(string(body)))
+		}
+		out := nacosContent(c.DataID, c.Group, string(body))
+		if beta, _ := strconv.ParseBool(resp.Header.Get("isBeta")); beta {
+			out.Meta["beta"] = "true"
+		}
+		if tag != "" {
+			out.Meta["tag"] = tag
+		}
+		return out, nil
+	}
+	return nacosContent(c.DataID, c.Group, ""), nil
+}
+
+// listenVariants 以当前各 dataId 的 md5 长轮询 /v1/cs/configs/listener 一次。服务端按本实例的 IP（Beta）
+// 与 Vipserver-Tag 请求头（标签，不存在时为正式版本）选择比较的版本，因此只发布标签或灰度版本也能感知；
+// 有变化的 dataId 重新读取后通知。首次调用使用 Open 读到的 md5，期间发布的变更不会丢失。
+func (p *NacosProvider) listenVariants(ctx context.Context, onChange func([]Content) error) error {
+	var b strings.Builder
+	var watched []NacosConfig
+	p.mu.Lock()
+	for _, c := range p.configs() {
+		if !c.Refresh {
+			continue
+		}
+		var sum string
+		for _, old := range p.last {
+			if old.ID == c.DataID && old.Group == c.Group {
+				sum = old.Meta["md5"]
+			}
+		}
+		b.WriteString(c.DataID + "\x02" + c.Group + "\x02" + sum)
+		if p.NamespaceID != "" {
+			b.WriteString("\x02" + p.NamespaceID)
+		}
+		b.WriteString("\x01")
+		watched = append(watched, c)
+	}
+	p.mu.Unlock()
+	if len(watched) == 0 {
+		<-ctx.Done()
+		return nil
+	}
+	changed, err := p.httpListen(ctx, b.String())
+	if err != nil {
+		return err
+	}
+	for _, c := range watched {
+		if !changed[c.DataID+"@"+c.Group] {
+			continue
+		}
+		content, err := p.httpGet(c)
+		if err != nil {
+			return fmt.Errorf("nacos get %s@%s: %w", c.DataID, c.Group, err)
+		}
+		p.push(content, onChange)
+	}
+	return nil
+}
+
+// httpListen 依次尝试各服务器发起一次长轮询，返回有变化的 dataId@group。
+func (p *NacosProvider) httpListen(ctx context.Context, listening string) (map[string]bool, error) {
+	bases, err := p.httpBases()
+	if err != nil {
+		return nil, err
+	}
+	timeout := p.longPollTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	cli := &http.Client{Timeout: timeout + time.Duration(p.timeoutMs)*time.Millisecond}
+	var lastErr error
+	for _, base := range bases {
+		form := url.Values{"Listening-Configs": {listening}}.Encode()
+		req, err := p.newRequest(ctx, http.MethodPost, base, "/v1/cs/configs/listener", url.Values{}, strings.NewReader(form), "")
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Long-Pulling-Timeout", strconv.FormatInt(timeout.Milliseconds(), 10))
+		if p.Tag != "" {
+			req.Header.Set("Vipserver-Tag", p.Tag)
+		}
+		resp, err := cli.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			lastErr = fmt.Errorf("nacos %s listener: %s: %s", base, resp.Status, strings.TrimSpace(string(body)))
+			continue
+		}
+		// 响应为 URL 编码的 dataId^2group[^2tenant]^1 列表
+		decoded, err := url.QueryUnescape(strings.TrimSpace(string(body)))
+		if err != nil {
+			return nil, fmt.Errorf("nacos %s listener: %w", base, err)
+		}
+		changed := map[string]bool{}
+		for _, line := range strings.Split(decoded, "\x01") {
+			if parts := strings.Split(line, "\x02"); len(parts) >= 2 {
+				changed[parts[0]+"@"+parts[1]] = true
+			}
+		}
+		return changed, nil
+	}
+	return nil, lastErr
+}
+
+// accessToken 在设置了用户名时登录并缓存令牌，提前一成有效期刷新。
+func (p *NacosProvider) accessToken(base string) (string, error) {
+	if p.Username == "" {
+		return "", nil
+	}
+	p.mu.Lock()
+	t := p.token
+	p.mu.Unlock()
+	if t.value != "" && time.Now().Before(t.expires) {
+		return t.value, nil
+	}
+	user, err := ResolveSecret(p.Username)
+	if err != nil {
+		return "", fmt.Errorf("nacos username: %w", err)
+	}
+	pass, err := ResolveSecret(p.Password)
+	if err != nil {
+		return "", fmt.Errorf("nacos password: %w", err)
+	}
+	resp, err := p.httpClient().PostForm(base+"/v1/auth/login", url.Values{"username": {user}, "password": {pass}})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("nacos login: %s", resp.Status)
+	}
+	var body struct {
+		AccessToken string `json:"accessToken"`
+		TokenTTL    int64  `json:"tokenTtl"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("nacos login: %w", err)
+	}
+	ttl := time.Duration(body.TokenTTL) * time.Second
+	p.mu.Lock()
+	p.token = nacosToken{value: body.AccessToken, expires: time.Now().Add(ttl * 9 / 10)}
+	p.mu.Unlock()
+	return body.AccessToken, nil
+}
@@ -0,0 +1,396 @@
+package provider
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nacos-group/nacos-sdk-go/v2/clients/config_client"
+	"github.com/nacos-group/nacos-sdk-go/v2/vo"
+)
+
+// fakeNacos 以 dataId@group 为键保存配置并记录订阅，未用到的方法未实现。
+type fakeNacos struct {
+	config_client.IConfigClient
+	mu      sync.Mutex
+	data    map[string]string
+	listens map[string]func(namespace, group, dataId, data string)
+	cancels int
+	closes  int
+	err     error // 非空时 GetConfig 失败，模拟服务端不可达
+}
+
+func newFakeNacos() *fakeNacos {
+	return &fakeNacos{data: map[string]string{}, listens: map[string]func(string, string, string, string){}}
+}
+
+func (f *fakeNacos) GetConfig(param vo.ConfigParam) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return "", f.err
+	}
+	return f.data[param.DataId+"@"+param.Group], nil
+}
+
+func (f *fakeNacos) ListenConfig(param vo.ConfigParam) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.listens[param.DataId+"@"+param.Group] = param.OnChange
+	return nil
+}
+
+func (f *fakeNacos) CancelListenConfig(param vo.ConfigParam) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.listens, param.DataId+"@"+param.Group)
+	f.cancels++
+	return nil
+}
+
+func (f *fakeNacos) CloseClient() { f.closes++ }
+
+// publish 修改配置并回调订阅者。
+func (f *fakeNacos) publish(dataID, group, content string) {
+	f.mu.Lock()
+	f.data[dataID+"@"+group] = content
+	cb := f.listens[dataID+"@"+group]
+	f.mu.Unlock()
+	if cb != nil {
+		cb("", group, dataID, content)
+	}
+}
+
+func TestParseNacosConfigs(t *testing.T) {
+	cs, err := ParseNacosConfigs("common.yaml, db.yaml@SHARED;refresh=false ,")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := []NacosConfig{{DataID: "common.yaml", Refresh: true}, {DataID: "db.yaml", Group: "SHARED"}}
+	if len(cs) != 2 || cs[0] != want[0] || cs[1] != want[1] {
+		t.Fatalf("got %+v", cs)
+	}
+	for _, bad := range []string{"@G", "a;x=1", "a;refresh=maybe"} {
+		if _, err := ParseNacosConfigs(bad); err == nil {
+			t.Fatalf("%q: want error", bad)
+		}
+	}
+}
+
+func TestNacos_MultipleDataIDs(t *testing.T) {
+	f := newFakeNacos()
+	f.data["common.yaml@SHARED"] = "a: 1\n"
+	f.data["db.yaml@DEFAULT_GROUP"] = "b: 1\n"
+	f.data["app.yaml@DEFAULT_GROUP"] = "a: 2\n"
+	p := NewNacosWithClient(f, "DEFAULT_GROUP", "app.yaml")
+	p.Configs = []NacosConfig{{DataID: "common.yaml", Group: "SHARED", Refresh: true}, {DataID: "db.yaml"}}
+	cs, err := p.Open()
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	var ids []string
+	for _, c := range cs {
+		ids = append(ids, c.ID+"@"+c.Group)
+	}
+	if len(ids) != 3 || ids[0] != "common.yaml@SHARED" || ids[1] != "db.yaml@DEFAULT_GROUP" || ids[2] != "app.yaml@DEFAULT_GROUP" {
+		t.Fatalf("order: %v", ids)
+	}
+
+	changes := 0
+	if err := p.Watch(func() error { changes++; return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	if len(f.listens) != 2 || f.listens["db.yaml@DEFAULT_GROUP"] != nil {
+		t.Fatalf("refresh=false should not be listened: %v", len(f.listens))
+	}
+	f.publish("common.yaml", "SHARED", "a: 3\n")
+	f.publish("db.yaml", "DEFAULT_GROUP", "b: 2\n")
+	if changes != 1 {
+		t.Fatalf("want 1 change, got %d", changes)
+	}
+	if err := p.Close(); err != nil || f.cancels != 2 || len(f.listens) != 0 {
+		t.Fatalf("close: %v cancels=%d", err, f.cancels)
+	}
+}
+
+func (f *fakeNacos) setErr(err error) {
+	f.mu.Lock()
+	f.err = err
+	f.mu.Unlock()
+}
+
+func TestNacos_Failover(t *testing.T) {
+	f := newFakeNacos()
+	f.data["app.yaml@DEFAULT_GROUP"] = "a: 1\n"
+	p := NewNacosWithClient(f, "DEFAULT_GROUP", "app.yaml")
+	p.CacheDir = t.TempDir()
+	p.Failover = true
+	p.RetryInterval = 10 * time.Millisecond
+	defer p.Close()
+	if _, err := p.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	f.setErr(errors.New("connection refused"))
+	f.data["app.yaml@DEFAULT_GROUP"] = "a: 2\n"
+	cs, err := p.Open()
+	if err != nil || len(cs) != 1 || cs[0].Payload != "a: 1\n" {
+		t.Fatalf("want snapshot, got %+v %v", cs, err)
+	}
+	if h := p.Health(); h.OK || h.Err == nil {
+		t.Fatalf("want degraded, got %+v", h)
+	}
+
+	reloaded := make(chan struct{}, 1)
+	if err := p.Watch(func() error { reloaded <- struct{}{}; return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	f.setErr(nil)
+	select {
+	case <-reloaded:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no reload after recovery")
+	}
+	if !p.Health().OK {
+		t.Fatalf("want healthy after recovery")
+	}
+	cs, err = p.Open()
+	if err != nil || cs[0].Payload != "a: 2\n" {
+		t.Fatalf("want live config, got %+v %v", cs, err)
+	}
+}
+
+func TestNacos_FailoverNoSnapshot(t *testing.T) {
+	f := newFakeNacos()
+	f.err = errors.New("connection refused")
+	p := NewNacosWithClient(f, "DEFAULT_GROUP", "app.yaml")
+	p.CacheDir = t.TempDir()
+	p.Failover = true
+	if _, err := p.Open(); err == nil {
+		t.Fatalf("want error without snapshot")
+	}
+	if !p.Health().OK {
+		t.Fatalf("no snapshot served, health should stay ok")
+	}
+}
+
+func TestNacos_WatchContentsPush(t *testing.T) {
+	f := newFakeNacos()
+	f.data["common.yaml@DEFAULT_GROUP"] = "a: 1\n"
+	f.data["app.yaml@DEFAULT_GROUP"] = "b: 1\n"
+	p := NewNacosWithClient(f, "DEFAULT_GROUP", "app.yaml")
+	p.Configs = []NacosConfig{{DataID: "common.yaml", Refresh: true}}
+	if _, err := p.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	var got [][]Content
+	if err := p.WatchContents(func(cs []Content) error { got = append(got, cs); return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	// 推送的数据直接使用，不再调用 GetConfig
+	f.setErr(errors.New("should not be called"))
+	f.publish("common.yaml", "DEFAULT_GROUP", "a: 2\n")
+	f.publish("common.yaml", "DEFAULT_GROUP", "a: 2\n")
+	if len(got) != 1 {
+		t.Fatalf("duplicate md5 should be ignored, got %d pushes", len(got))
+	}
+	cs := got[0]
+	if len(cs) != 2 || cs[0].ID != "common.yaml" || cs[0].Payload != "a: 2\n" || cs[1].Payload != "b: 1\n" {
+		t.Fatalf("contents: %+v", cs)
+	}
+	if cs[0].Meta["md5"] != "9fb53a123ad2bd8dcb70db98713fa667" {
+		t.Fatalf("md5: %q", cs[0].Meta["md5"])
+	}
+}
+
+func TestNacos_TagAndBeta(t *testing.T) {
+	logins := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/nacos/v1/auth/login":
+			logins++
+			if r.FormValue("username") != "nacos" || r.FormValue("password") != "pw" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_, _ = w.Write([]byte(`{"accessToken":"tok","tokenTtl":18000}`))
+		case "/nacos/v1/cs/configs":
+			q := r.URL.Query()
+			if q.Get("accessToken") != "tok" || q.Get("tenant") != "ns" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			switch {
+			case q.Get("dataId") == "app.yaml" && q.Get("tag") == "canary":
+				_, _ = w.Write([]byte("a: canary\n"))
+			case q.Get("dataId") == "app.yaml":
+				_, _ = w.Write([]byte("a: 1\n"))
+			case q.Get("dataId") == "common.yaml" && q.Get("tag") == "":
+				w.Header().Set("isBeta", "true")
+				_, _ = w.Write([]byte("b: beta\n"))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	f := newFakeNacos()
+	p := NewNacosWithClient(f, "DEFAULT_GROUP", "app.yaml")
+	p.ServerAddrs = []string{srv.URL + "/nacos"}
+	p.NamespaceID = "ns"
+	p.Username, p.Password = "nacos", "pw"
+	p.Configs = []NacosConfig{{DataID: "common.yaml", Refresh: true}}
+	p.Tag = "canary"
+	cs, err := p.Open()
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if len(cs) != 2 {
+		t.Fatalf("contents: %+v", cs)
+	}
+	// common.yaml 没有 canary 标签，回退到正式（此处为 Beta）版本
+	if cs[0].Payload != "b: beta\n" || cs[0].Meta["beta"] != "true" || cs[0].Meta["tag"] != "" {
+		t.Fatalf("common: %+v", cs[0])
+	}
+	if cs[1].Payload != "a: canary\n" || cs[1].Meta["tag"] != "canary" || cs[1].Meta["beta"] != "" {
+		t.Fatalf("app: %+v", cs[1])
+	}
+	if _, err := p.Open(); err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if logins != 1 {
+		t.Fatalf("token should be cached, logins=%d", logins)
+	}
+}
+
+func TestNacos_TagWatch(t *testing.T) {
+	var mu sync.Mutex
+	data := map[string]string{"app.yaml": "a: 1\n", "app.yaml#canary": "a: canary\n"}
+	published := make(chan struct{})
+	publish := func(key, content string) {
+		mu.Lock()
+		defer mu.Unlock()
+		data[key] = content
+		close(published)
+		published = make(chan struct{})
+	}
+	// variant 返回 tag 对应的版本，不存在时为正式版本，调用时需持有 mu
+	variant := func(dataID, tag string) (string, bool) {
+		if v, ok := data[dataID+"#"+tag]; ok && tag != "" {
+			return v, true
+		}
+		return data[dataID], tag == ""
+	}
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/nacos/serverlist" {
+			_, _ = w.Write([]byte(strings.TrimPrefix(srv.URL, "http://") + "\n"))
+			return
+		}
+		ts := r.Header.Get("Timestamp")
+		if r.Header.Get("Spas-AccessKey") != "ak" || r.Header.Get("Spas-Signature") != nacosSignature("sk", "ns", r.FormValue("group"), ts) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/nacos/v1/cs/configs":
+			mu.Lock()
+			v, ok := variant(r.FormValue("dataId"), r.FormValue("tag"))
+			mu.Unlock()
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(v))
+		case "/nacos/v1/cs/configs/listener":
+			ms, _ := strconv.Atoi(r.Header.Get("Long-Pulling-Timeout"))
+			deadline := time.After(time.Duration(ms) * time.Millisecond)
+			for {
+				var changed strings.Builder
+				mu.Lock()
+				wait := published
+				for _, line := range strings.Split(r.FormValue("Listening-Configs"), "\x01") {
+					parts := strings.Split(line, "\x02")
+					if len(parts) < 3 {
+						continue
+					}
+					v, _ := variant(parts[0], r.Header.Get("Vipserver-Tag"))
+					if sum := md5.Sum([]byte(v)); hex.EncodeToString(sum[:]) != parts[2] {
+						changed.WriteString(parts[0] + "\x02" + parts[1] + "\x02ns\x01")
+					}
+				}
+				mu.Unlock()
+				if changed.Len() > 0 {
+					_, _ = w.Write([]byte(url.QueryEscape(changed.String())))
+					return
+				}
+				select {
+				case <-wait:
+				case <-deadline:
+					return
+				case <-r.Context().Done():
+					return
+				}
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	// 地址服务器模式 + AccessKey/SecretKey 签名
+	p := NewNacosWithClient(newFakeNacos(), "DEFAULT_GROUP", "app.yaml")
+	p.Endpoint = strings.TrimPrefix(srv.URL, "http://")
+	p.NamespaceID = "ns"
+	p.AccessKey, p.SecretKey = "ak", "sk"
+	p.Tag = "canary"
+	p.RetryInterval = 10 * time.Millisecond
+	p.longPollTimeout = 200 * time.Millisecond
+	defer p.Close()
+	cs, err := p.Open()
+	if err != nil || len(cs) != 1 || cs[0].Payload != "a: canary\n" {
+		t.Fatalf("open: %v %+v", err, cs)
+	}
+	got := make(chan []Content, 4)
+	// Open 与开始监听之间发布的标签版本也能感知
+	publish("app.yaml#canary", "a: canary2\n")
+	if err := p.WatchContents(func(cs []Content) error { got <- cs; return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	expect := func(payload string) {
+		t.Helper()
+		select {
+		case cs := <-got:
+			if len(cs) != 1 || cs[0].Payload != payload || cs[0].Meta["tag"] != "canary" {
+				t.Fatalf("contents: %+v", cs)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("no reload for %q, health %+v", payload, p.Health())
+		}
+	}
+	expect("a: canary2\n")
+	// 只重新发布标签版本即触发重新加载
+	publish("app.yaml#canary", "a: canary3\n")
+	expect("a: canary3\n")
+	// 正式版本变化不影响标签版本
+	publish("app.yaml", "a: 2\n")
+	select {
+	case cs := <-got:
+		t.Fatalf("unexpected reload: %+v", cs)
+	case <-time.After(300 * time.Millisecond):
+	}
+	if !p.Health().OK {
+		t.Fatalf("want healthy: %+v", p.Health())
+	}
+}
@@ -0,0 +1,319 @@
+package provider
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ObjectStoreProvider 从 S3 兼容的对象存储（AWS S3、MinIO 等）读取一个对象，或 Prefix 为 true 时
+// 读取前缀下的全部对象（按 key 顺序合并），请求使用 SigV4 签名。按 Interval 轮询对象的 ETag，
+// 仅重新下载 ETag 变化的对象。
+type ObjectStoreProvider struct {
+	Endpoint     string // 例如 http://minio:9000；默认 https://s3.<Region>.amazonaws.com
+	Region       string // 默认 us-east-1
+	Bucket       string
+	Key          string
+	Prefix       bool
+	PathStyle    bool   // 使用 <endpoint>/<bucket>/<key> 形式的地址，MinIO 等通常需要开启
+	AccessKey    string // 以下凭据均支持 file:/path 与 env:NAME，见 ResolveSecret；为空时匿名访问
+	SecretKey    string
+	SessionToken string
+	TLS          TLSOptions
+	Timeout      time.Duration
+
+	Interval      time.Duration // ETag 轮询间隔，0 表示不监听
+	RetryInterval time.Duration // 请求失败后首次重试的间隔，默认 1s
+
+	watcher
+	mu      sync.Mutex
+	cli     *http.Client
+	objects map[string]Content // 按 key 缓存已下载的对象，Meta["etag"] 为其 ETag
+	sig     string             // 最近一次读取的 key 与 ETag
+}
+
+func NewObjectStore(endpoint, bucket, key string) *ObjectStoreProvider {
+	return &ObjectStoreProvider{Endpoint: endpoint, Region: "us-east-1", Bucket: bucket, Key: key,
+		Timeout: 30 * time.Second, RetryInterval: time.Second}
+}
+
+// NewObjectStorePrefix 创建前缀模式的 ObjectStoreProvider，读取并监听 prefix 下的全部对象。
+func NewObjectStorePrefix(endpoint, bucket, prefix string) *ObjectStoreProvider {
+	p := NewObjectStore(endpoint, bucket, prefix)
+	p.Prefix = true
+	return p
+}
+
+func (p *ObjectStoreProvider) client() (*http.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cli != nil {
+		return p.cli, nil
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	if p.TLS.Enabled() {
+		cfg, err := p.TLS.ConfigFor(tlsHost(p.Endpoint))
+		if err != nil {
+			return nil, err
+		}
+		tr.TLSClientConfig = cfg
+	}
+	p.cli = &http.Client{Transport: tr}
+	return p.cli, nil
+}
+
+func (p *ObjectStoreProvider) region() string {
+	if p.Region == "" {
+		return "us-east-1"
+	}
+	return p.Region
+}
+
+// objectURL 返回 key 的地址；key 为空时返回 bucket 的地址（用于列举）。
+func (p *ObjectStoreProvider) objectURL(key string) (*url.URL, error) {
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + p.region() + ".amazonaws.com"
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("s3 endpoint: %w", err)
+	}
+	base := strings.TrimSuffix(u.Path, "/")
+	if p.PathStyle {
+		u.Path = base + "/" + p.Bucket + "/" + key
+	} else {
+		u.Host = p.Bucket + "." + u.Host
+		u.Path = base + "/" + key
+	}
+	return u, nil
+}
+
+// do 发起签名请求并返回响应，调用方负责关闭响应体。
+func (p *ObjectStoreProvider) do(ctx context.Context, method string, u *url.URL) (*http.Response, error) {
+	cli, err := p.client()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.AccessKey != "" {
+		ak, err := ResolveSecret(p.AccessKey)
+		if err != nil {
+			return nil, fmt.Errorf("s3 access key: %w", err)
+		}
+		sk, err := ResolveSecret(p.SecretKey)
+		if err != nil {
+			return nil, fmt.Errorf("s3 secret key: %w", err)
+		}
+		token, err := ResolveSecret(p.SessionToken)
+		if err != nil {
+			return nil, fmt.Errorf("s3 session token: %w", err)
+		}
+		signV4(req, ak, sk, token, p.region(), time.Now())
+	}
+	return cli.Do(req)
+}
+
+// s3Error 读取错误响应中的 Code 与 Message。
+func s3Error(op string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var e struct {
+		Code    string
+		Message string
+	}
+	if xml.Unmarshal(body, &e) == nil && e.Code != "" {
+		return fmt.Errorf("s3 %s: %s: %s: %s", op, resp.Status, e.Code, e.Message)
+	}
+	return fmt.Errorf("s3 %s: %s", op, resp.Status)
+}
+
+// objectInfo 为列举或 HEAD 得到的对象 key 与 ETag。
+type objectInfo struct {
+	Key  string
+	ETag string
+}
+
+// list 返回需要读取的对象：前缀模式列举前缀下的对象，否则 HEAD 单个对象。
+func (p *ObjectStoreProvider) list(ctx context.Context) ([]objectInfo, error) {
+	if p.Bucket == "" || (p.Key == "" && !p.Prefix) {
+		return nil, errors.New("s3 bucket and key are required")
+	}
+	if !p.Prefix {
+		u, err := p.objectURL(p.Key)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := p.do(ctx, http.MethodHead, u)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("s3 head %s/%s: %s", p.Bucket, p.Key, resp.Status)
+		}
+		return []objectInfo{{Key: p.Key, ETag: resp.Header.Get("ETag")}}, nil
+	}
+	var out []objectInfo
+	token := ""
+	for {
+		u, err := p.objectURL("")
+		if err != nil {
+			return nil, err
+		}
+		q := url.Values{"list-type": {"2"}, "prefix": {p.Key}}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		u.RawQuery = q.Encode()
+		resp, err := p.do(ctx, http.MethodGet, u)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			err := s3Error("list "+p.Bucket+"/"+p.Key, resp)
+			resp.Body.Close()
+			return nil, err
+		}
+		var page struct {
+			Contents              []objectInfo
+			IsTruncated           bool
+			NextContinuationToken string
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("s3 list %s/%s: %w", p.Bucket, p.Key, err)
+		}
+		for _, o := range page.Contents {
+			// 控制台创建的“目录”是以 / 结尾的空对象
+			if !strings.HasSuffix(o.Key, "/") {
+				out = append(out, o)
+			}
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			break
+		}
+		token = page.NextContinuationToken
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
+
+// get 下载对象。
+func (p *ObjectStoreProvider) get(ctx context.Context, key string) (Content, error) {
+	u, err := p.objectURL(key)
+	if err != nil {
+		return Content{}, err
+	}
+	resp, err := p.do(ctx, http.MethodGet, u)
+	if err != nil {
+		return Content{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Content{}, s3Error("get "+p.Bucket+"/"+key, resp)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Content{}, err
+	}
+	meta := map[string]string{"bucket": p.Bucket, "etag": resp.Header.Get("ETag")}
+	if v := resp.Header.Get("Last-Modified"); v != "" {
+		meta["last-modified"] = v
+	}
+	return Content{ID: key, Group: "s3", Payload: string(body), Meta: meta}, nil
+}
+
+func (p *ObjectStoreProvider) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+func (p *ObjectStoreProvider) Open() ([]Content, error) {
+	_, out, err := p.check(context.Background())
+	return out, err
+}
+
+// check 列举对象，下载 ETag 变化的对象，返回内容是否变化及全部内容。
+func (p *ObjectStoreProvider) check(ctx context.Context) (bool, []Content, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+	infos, err := p.list(ctx)
+	if err != nil {
+		return false, nil, err
+	}
+	var sig strings.Builder
+	for _, o := range infos {
+		fmt.Fprintf(&sig, "%s@%s;", o.Key, o.ETag)
+	}
+	p.mu.Lock()
+	cached := p.objects
+	changed := sig.String() != p.sig
+	p.mu.Unlock()
+	objects := make(map[string]Content, len(infos))
+	out := make([]Content, 0, len(infos))
+	for _, o := range infos {
+		c, ok := cached[o.Key]
+		if !ok || o.ETag == "" || c.Meta["etag"] != o.ETag {
+			if c, err = p.get(ctx, o.Key); err != nil {
+				return false, nil, err
+			}
+		}
+		objects[o.Key] = c
+		out = append(out, c)
+	}
+	p.mu.Lock()
+	p.objects, p.sig = objects, sig.String()
+	p.mu.Unlock()
+	return changed, out, nil
+}
+
+func (p *ObjectStoreProvider) Watch(onChange func() error) error {
+	return p.WatchContents(func([]Content) error { return onChange() })
+}
+
+// WatchContents 按 Interval 轮询对象的 ETag，有对象新增、删除或 ETag 变化时携带全部内容通知。
+func (p *ObjectStoreProvider) WatchContents(onChange func([]Content) error) error {
+	if p.Interval <= 0 {
+		return nil
+	}
+	if _, err := p.client(); err != nil {
+		return err
+	}
+	p.startWatch(p.Interval, p.RetryInterval, func(ctx context.Context) error {
+		changed, out, err := p.check(ctx)
+		if changed {
+			_ = onChange(out)
+		}
+		return err
+	})
+	return nil
+}
+
+// Close 停止轮询。
+func (p *ObjectStoreProvider) Close() error {
+	p.stopWatch()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cli != nil {
+		p.cli.CloseIdleConnections()
+	}
+	return nil
+}
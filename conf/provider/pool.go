@@ -0,0 +1,212 @@
+package provider
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nacos-group/nacos-sdk-go/v2/clients/config_client"
+	"github.com/nacos-group/nacos-sdk-go/v2/vo"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// ClientPool 按连接参数共享 etcd 与 Nacos 客户端，并按引用计数管理生命周期：
+// 参数相同的 Provider 复用同一连接，最后一个使用者释放后才关闭。
+type ClientPool struct {
+	mu    sync.Mutex
+	etcd  map[etcdPoolKey]*pooledEtcd
+	nacos map[nacosPoolKey]*pooledNacos
+}
+
+type etcdPoolKey struct {
+	endpoints string
+	username  string
+	password  string
+	tls       TLSOptions
+}
+
+type nacosPoolKey struct {
+	servers, namespace string
+	timeoutMs          uint64
+	username, password string
+	accessKey, secret  string
+	endpoint, regionID string
+	openKMS            bool
+	cacheDir           string
+	grpcPortOffset     uint64
+	appName            string
+}
+
+// pooled 是池中的一个客户端。建连在锁外进行，期间相同参数的调用方等待 ready，
+// 并已计入 refs，因此建连完成前不会被其他使用者的 release 关闭。
+type pooled[C any] struct {
+	ready chan struct{}
+	cli   C
+	err   error // 建连失败时非空，条目随即从池中移除
+	refs  int
+}
+
+type pooledEtcd = pooled[*clientv3.Client]
+type pooledNacos = pooled[*nacosShared]
+
+func NewClientPool() *ClientPool {
+	return &ClientPool{etcd: map[etcdPoolKey]*pooledEtcd{}, nacos: map[nacosPoolKey]*pooledNacos{}}
+}
+
+// acquire 取得 m 中 key 对应的客户端，不存在时调用 dial 创建；release 在最后一个使用者释放后调用 closeCli。
+func acquire[K comparable, C any](mu *sync.Mutex, m map[K]*pooled[C], key K, dial func() (C, error), closeCli func(C) error) (C, func() error, error) {
+	mu.Lock()
+	e := m[key]
+	if e == nil {
+		e = &pooled[C]{ready: make(chan struct{})}
+		m[key] = e
+		e.refs++
+		mu.Unlock()
+		// 建连可能阻塞至超时，不能持有池的锁
+		e.cli, e.err = dial()
+		close(e.ready)
+		if e.err != nil {
+			mu.Lock()
+			delete(m, key)
+			mu.Unlock()
+		}
+	} else {
+		e.refs++
+		mu.Unlock()
+		<-e.ready
+	}
+	if e.err != nil {
+		var zero C
+		return zero, nil, e.err
+	}
+	var once sync.Once
+	release := func() error {
+		var err error
+		once.Do(func() {
+			mu.Lock()
+			e.refs--
+			last := e.refs == 0
+			if last {
+				delete(m, key)
+			}
+			mu.Unlock()
+			if last {
+				err = closeCli(e.cli)
+			}
+		})
+		return err
+	}
+	return e.cli, release, nil
+}
+
+// EtcdClientOptions 描述 etcd 连接参数，DialTimeout 不参与共享判断。
+type EtcdClientOptions struct {
+	Endpoints   []string
+	Username    string
+	Password    string
+	DialTimeout time.Duration
+	TLS         TLSOptions
+}
+
+// Etcd 获取与 o 对应的共享客户端，使用完毕后调用 release。
+func (pool *ClientPool) Etcd(o EtcdClientOptions) (cli *clientv3.Client, release func() error, err error) {
+	key := etcdPoolKey{endpoints: strings.Join(o.Endpoints, ","), username: o.Username, password: o.Password, tls: o.TLS}
+	return acquire(&pool.mu, pool.etcd, key,
+		func() (*clientv3.Client, error) { return newEtcdClient(o) },
+		func(c *clientv3.Client) error { return c.Close() })
+}
+
+// Nacos 获取与 o 对应的共享客户端，使用完毕后调用 release。
+// 每次调用返回独立的句柄：同一 dataId/group 的订阅在底层客户端上只注册一次，
+// 推送分发给所有订阅了它的句柄；句柄取消订阅只移除自己的回调，最后一个取消时才真正取消。
+func (pool *ClientPool) Nacos(o NacosClientOptions) (cli config_client.IConfigClient, release func() error, err error) {
+	key := nacosPoolKey{
+		servers: strings.Join(o.ServerAddrs, ","), namespace: o.NamespaceID, timeoutMs: o.TimeoutMs,
+		username: o.Username, password: o.Password, accessKey: o.AccessKey, secret: o.SecretKey,
+		endpoint: o.Endpoint, regionID: o.RegionID, openKMS: o.OpenKMS, cacheDir: o.CacheDir,
+		grpcPortOffset: o.GrpcPortOffset, appName: o.AppName,
+	}
+	shared, release, err := acquire(&pool.mu, pool.nacos, key,
+		func() (*nacosShared, error) {
+			c, err := newNacosClient(o)
+			if err != nil {
+				return nil, err
+			}
+			return &nacosShared{cli: c, listeners: map[string]map[*nacosHandle]nacosListener{}}, nil
+		},
+		func(s *nacosShared) error { s.cli.CloseClient(); return nil })
+	if err != nil {
+		return nil, nil, err
+	}
+	return &nacosHandle{IConfigClient: shared.cli, shared: shared}, release, nil
+}
+
+// Len 返回池中仍在使用的 etcd 与 Nacos 客户端数量。
+func (pool *ClientPool) Len() (etcd, nacos int) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	return len(pool.etcd), len(pool.nacos)
+}
+
+type nacosListener = func(namespace, group, dataId, data string)
+
+// nacosShared 是池中共享的 Nacos 客户端及其订阅。SDK 对同一 dataId/group 只保留首个监听器，
+// 取消时也会一并移除，因此由这里统一注册并分发给各个句柄。
+type nacosShared struct {
+	cli       config_client.IConfigClient
+	mu        sync.Mutex
+	listeners map[string]map[*nacosHandle]nacosListener // dataId@group -> 句柄的回调
+}
+
+// nacosHandle 是共享 Nacos 客户端的一个使用者，订阅相关方法经 nacosShared 分发，
+// CloseClient 不做任何事，底层客户端由池在最后一个使用者释放后关闭。
+type nacosHandle struct {
+	config_client.IConfigClient
+	shared *nacosShared
+}
+
+func (h *nacosHandle) ListenConfig(param vo.ConfigParam) error {
+	s := h.shared
+	key := param.DataId + "@" + param.Group
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if subs := s.listeners[key]; subs != nil {
+		subs[h] = param.OnChange
+		return nil
+	}
+	listen := param
+	listen.OnChange = func(namespace, group, dataId, data string) {
+		s.mu.Lock()
+		var fns []nacosListener
+		for _, fn := range s.listeners[key] {
+			fns = append(fns, fn)
+		}
+		s.mu.Unlock()
+		for _, fn := range fns {
+			fn(namespace, group, dataId, data)
+		}
+	}
+	if err := s.cli.ListenConfig(listen); err != nil {
+		return err
+	}
+	s.listeners[key] = map[*nacosHandle]nacosListener{h: param.OnChange}
+	return nil
+}
+
+func (h *nacosHandle) CancelListenConfig(param vo.ConfigParam) error {
+	s := h.shared
+	key := param.DataId + "@" + param.Group
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	subs := s.listeners[key]
+	if _, ok := subs[h]; !ok {
+		return nil
+	}
+	if delete(subs, h); len(subs) > 0 {
+		return nil
+	}
+	delete(s.listeners, key)
+	return s.cli.CancelListenConfig(param)
+}
+
+func (h *nacosHandle) CloseClient() {}
@@ -0,0 +1,192 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"config-loader/internal/etcdtest"
+)
+
+func TestClientPool_EtcdShared(t *testing.T) {
+	pool := NewClientPool()
+	a := NewEtcd([]string{"127.0.0.1:1"}, "/a", "", "")
+	b := NewEtcd([]string{"127.0.0.1:1"}, "/b", "", "")
+	c := NewEtcd([]string{"127.0.0.1:2"}, "/c", "", "")
+	for _, p := range []*EtcdProvider{a, b, c} {
+		p.Pool = pool
+		if err := p.ensureClient(); err != nil {
+			t.Fatalf("ensure: %v", err)
+		}
+	}
+	if a.cli != b.cli || a.cli == c.cli {
+		t.Fatalf("clients should be shared by connection params")
+	}
+	if n, _ := pool.Len(); n != 2 {
+		t.Fatalf("want 2 pooled clients, got %d", n)
+	}
+	if err := a.Close(); err != nil {
+		t.Fatalf("close a: %v", err)
+	}
+	// 重复关闭不应重复释放
+	_ = a.Close()
+	if n, _ := pool.Len(); n != 2 {
+		t.Fatalf("client released while still in use")
+	}
+	if b.cli.Ctx().Err() != nil {
+		t.Fatalf("shared client closed early")
+	}
+	_ = b.Close()
+	_ = c.Close()
+	if n, _ := pool.Len(); n != 0 {
+		t.Fatalf("want empty pool, got %d", n)
+	}
+}
+
+func TestEtcd_WithClientNotClosed(t *testing.T) {
+	s := etcdtest.New()
+	s.Put("/app/config", "a: 1\n")
+	cli := s.Client()
+	p := NewEtcdWithClient(cli, "/app/config")
+	cs, err := p.Open()
+	if err != nil || len(cs) != 1 {
+		t.Fatalf("open: %v %+v", err, cs)
+	}
+	// 测试客户端没有底层连接，若被关闭会 panic
+	if err := p.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if _, err := cli.Get(context.Background(), "/app/config"); err != nil {
+		t.Fatalf("injected client unusable: %v", err)
+	}
+}
+
+func TestNacos_WithClient(t *testing.T) {
+	f := newFakeNacos()
+	f.data["app.yaml@DEFAULT_GROUP"] = "a: 1\n"
+	p := NewNacosWithClient(f, "DEFAULT_GROUP", "app.yaml")
+	cs, err := p.Open()
+	if err != nil || len(cs) != 1 || cs[0].ID != "app.yaml" {
+		t.Fatalf("open: %v %+v", err, cs)
+	}
+	if err := p.Watch(func() error { return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if len(f.listens) != 0 || f.cancels != 1 || f.closes != 0 {
+		t.Fatalf("unexpected calls: %+v", f)
+	}
+}
+
+func TestClientPool_NacosShared(t *testing.T) {
+	pool := NewClientPool()
+	a := NewNacos([]string{"127.0.0.1:1"}, "", "DEFAULT_GROUP", "a")
+	b := NewNacos([]string{"127.0.0.1:1"}, "", "DEFAULT_GROUP", "b")
+	a.Pool, b.Pool = pool, pool
+	if err := a.ensureClient(); err != nil {
+		t.Fatalf("ensure: %v", err)
+	}
+	if err := b.ensureClient(); err != nil {
+		t.Fatalf("ensure: %v", err)
+	}
+	if a.cli.(*nacosHandle).shared != b.cli.(*nacosHandle).shared {
+		t.Fatalf("nacos client should be shared")
+	}
+	_ = a.Close()
+	if _, n := pool.Len(); n != 1 {
+		t.Fatalf("client released while still in use")
+	}
+	_ = b.Close()
+	if _, n := pool.Len(); n != 0 {
+		t.Fatalf("want empty pool, got %d", n)
+	}
+}
+
+func TestClientPool_DialOutsideLock(t *testing.T) {
+	var mu sync.Mutex
+	m := map[string]*pooled[string]{}
+	unblock := make(chan struct{})
+	var dials int32
+	slow := func() (string, error) { atomic.AddInt32(&dials, 1); <-unblock; return "a", nil }
+	got := make(chan string, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			c, _, err := acquire(&mu, m, "a", slow, func(string) error { return nil })
+			if err != nil {
+				t.Errorf("acquire a: %v", err)
+			}
+			got <- c
+		}()
+	}
+	// 其他参数的客户端不受正在建立的连接影响
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if c, _, err := acquire(&mu, m, "b", func() (string, error) { return "b", nil }, func(string) error { return nil }); err != nil || c != "b" {
+			t.Errorf("acquire b: %v %q", err, c)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("blocked by a pending dial")
+	}
+	close(unblock)
+	for i := 0; i < 2; i++ {
+		if c := <-got; c != "a" {
+			t.Fatalf("got %q", c)
+		}
+	}
+	if n := atomic.LoadInt32(&dials); n != 1 || m["a"].refs != 2 {
+		t.Fatalf("dials=%d refs=%d", n, m["a"].refs)
+	}
+
+	// 建连失败时不留在池中，下次重新建立
+	fail := func() (string, error) { return "", errors.New("dial failed") }
+	if _, _, err := acquire(&mu, m, "c", fail, func(string) error { return nil }); err == nil || m["c"] != nil {
+		t.Fatalf("want dial error and no entry: %v", err)
+	}
+}
+
+func TestClientPool_NacosSharedListeners(t *testing.T) {
+	f := newFakeNacos()
+	f.data["app.yaml@DEFAULT_GROUP"] = "a: 1\n"
+	shared := &nacosShared{cli: f, listeners: map[string]map[*nacosHandle]nacosListener{}}
+	a := NewNacosWithClient(&nacosHandle{IConfigClient: f, shared: shared}, "DEFAULT_GROUP", "app.yaml")
+	b := NewNacosWithClient(&nacosHandle{IConfigClient: f, shared: shared}, "DEFAULT_GROUP", "app.yaml")
+	var na, nb int32
+	for _, w := range []struct {
+		p *NacosProvider
+		n *int32
+	}{{a, &na}, {b, &nb}} {
+		if _, err := w.p.Open(); err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		n := w.n
+		if err := w.p.Watch(func() error { atomic.AddInt32(n, 1); return nil }); err != nil {
+			t.Fatalf("watch: %v", err)
+		}
+	}
+	f.publish("app.yaml", "DEFAULT_GROUP", "a: 2\n")
+	if na != 1 || nb != 1 {
+		t.Fatalf("both providers should be notified: %d %d", na, nb)
+	}
+	// 一个 Provider 关闭后另一个仍能收到推送
+	_ = a.Close()
+	if f.cancels != 0 {
+		t.Fatalf("listener cancelled while still in use")
+	}
+	f.publish("app.yaml", "DEFAULT_GROUP", "a: 3\n")
+	if na != 1 || nb != 2 {
+		t.Fatalf("after close: %d %d", na, nb)
+	}
+	_ = b.Close()
+	if f.cancels != 1 || len(f.listens) != 0 {
+		t.Fatalf("want listener cancelled: %+v", f)
+	}
+}
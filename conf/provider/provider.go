@@ -1,10 +1,18 @@
 package provider
 
+import "time"
+
 // Content 表示一个配置单元（例如一个 YAML 文档）。
 type Content struct {
 	ID      string
 	Group   string
 	Payload string
+
+	Revision int64 // 来源中该条目最近一次修改的修订（etcd 为 ModRevision），不支持时为 0
+	Version  int64 // 来源中该条目的版本（etcd 为 key 的 Version），不支持时为 0
+	Deleted  bool  // 条目已被删除，仅出现在变更事件中，解析时跳过
+
+	Meta map[string]string // 来源特有的元信息，例如 Nacos 的 md5
 }
 
 // Provider 是一个最小的配置源接口，支持打开与监听。
@@ -12,3 +20,22 @@ type Provider interface {
 	Open() ([]Content, error)
 	Watch(onChange func() error) error
 }
+
+// ContentWatcher 是可选接口：变更事件直接携带变更后的全部内容，
+// 调用方据此更新配置而无需再次 Open，避免读到与事件不一致的版本。
+type ContentWatcher interface {
+	WatchContents(onChange func([]Content) error) error
+}
+
+// Health 描述 Provider 监听通道的状态。
+type Health struct {
+	OK       bool      // 监听正常
+	Err      error     // 最近一次中断的原因
+	Since    time.Time // 当前状态的开始时间
+	Revision int64     // 最近确认的来源修订（若来源支持）
+}
+
+// HealthReporter 是可选接口，由能够感知监听状态的 Provider 实现。
+type HealthReporter interface {
+	Health() Health
+}
@@ -290,18 +290,36 @@ func TestNacos_EnsureClientError(t *testing.T) {
     }
 }
 
-func TestSplitHostPort(t *testing.T) {
-    h, p := splitHostPort("127.0.0.1:8848")
-    if h != "127.0.0.1" || p != 8848 {
-        t.Fatalf("bad: %s %d", h, p)
-    }
-    h, p = splitHostPort("bad")
-    if h != "bad" || p != 0 {
-        t.Fatalf("bad: %s %d", h, p)
-    }
-    h, p = splitHostPort("")
-    if h != "" || p != 0 {
-        t.Fatalf("bad: %s %d", h, p)
+func TestParseServerAddr(t *testing.T) {
+    cases := []struct {
+        in                  string
+        scheme, ip, context string
+        port, grpc          uint64
+    }{
+        {"127.0.0.1:8848", "http", "127.0.0.1", "/nacos", 8848, 9848},
+        {"bad", "http", "bad", "/nacos", 8848, 9848},
+        {"https://nacos.example.com", "https", "nacos.example.com", "/nacos", 8848, 9848},
+        {"http://10.0.0.1:8080/custom/", "http", "10.0.0.1", "/custom", 8080, 9080},
+        {"[::1]:8849", "http", "[::1]", "/nacos", 8849, 9849},
+        {"::1", "http", "[::1]", "/nacos", 8848, 9848},
+        {"https://[fe80::1]/nacos", "https", "[fe80::1]", "/nacos", 8848, 9848},
+    }
+    for _, c := range cases {
+        sc, err := parseServerAddr(c.in, 0)
+        if err != nil {
+            t.Fatalf("%q: %v", c.in, err)
+        }
+        if sc.Scheme != c.scheme || sc.IpAddr != c.ip || sc.ContextPath != c.context || sc.Port != c.port || sc.GrpcPort != c.grpc {
+            t.Fatalf("%q: got %+v", c.in, sc)
+        }
+    }
+    if sc, err := parseServerAddr("127.0.0.1:8848", 1001); err != nil || sc.GrpcPort != 9849 {
+        t.Fatalf("offset: %+v %v", sc, err)
+    }
+    for _, bad := range []string{"", "  ", "tcp://h:1", "h:abc", "h:0", "h:70000", "h:", "http://:8848", "u:p@h:1", "h:1?x=1", "1::2::3", "h:65000"} {
+        if _, err := parseServerAddr(bad, 0); err == nil {
+            t.Fatalf("%q: want error", bad)
+        }
     }
 }
 
@@ -0,0 +1,308 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisProvider 从 Redis 的 string 或 hash 键读取配置；Pattern 为 true 时 Key 为匹配模式，
+// 按键名顺序合并全部匹配的键。hash 的 field 按 "." 拆分为层级转换为 YAML。
+// 监听优先使用 keyspace 通知，服务端未开启（或无法确认）时按 Interval 轮询。
+type RedisProvider struct {
+	Addr     string // host:port，或 redis:// / rediss:// URL
+	Username string
+	Password string // 支持 file:/path 与 env:NAME，见 ResolveSecret
+	DB       int
+	TLS      TLSOptions
+	Key      string
+	Pattern  bool
+	Timeout  time.Duration
+
+	// Notify 控制是否使用 keyspace 通知：auto 时通过 CONFIG GET notify-keyspace-events 判断，
+	// on 时直接订阅（适用于禁用了 CONFIG 命令但已开启通知的托管实例），off 时只轮询。
+	Notify        string
+	Interval      time.Duration // 轮询间隔，默认 5s
+	RetryInterval time.Duration // 订阅或读取失败后首次重试的间隔，默认 1s
+
+	watcher
+	mu  sync.Mutex
+	cli *redis.Client
+	sig string // 最近一次读取内容的摘要
+}
+
+func NewRedis(addr, key string) *RedisProvider {
+	return &RedisProvider{Addr: addr, Key: key, Notify: "auto", Timeout: 10 * time.Second,
+		Interval: 5 * time.Second, RetryInterval: time.Second}
+}
+
+// NewRedisPattern 创建按模式匹配键的 RedisProvider。
+func NewRedisPattern(addr, pattern string) *RedisProvider {
+	p := NewRedis(addr, pattern)
+	p.Pattern = true
+	return p
+}
+
+func (p *RedisProvider) client() (*redis.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cli != nil {
+		return p.cli, nil
+	}
+	if p.Addr == "" || p.Key == "" {
+		return nil, errors.New("redis address and key are required")
+	}
+	opts := &redis.Options{Addr: p.Addr}
+	if strings.Contains(p.Addr, "://") {
+		var err error
+		if opts, err = redis.ParseURL(p.Addr); err != nil {
+			return nil, err
+		}
+	}
+	if p.Username != "" {
+		opts.Username = p.Username
+	}
+	if p.Password != "" {
+		pass, err := ResolveSecret(p.Password)
+		if err != nil {
+			return nil, fmt.Errorf("redis password: %w", err)
+		}
+		opts.Password = pass
+	}
+	if p.DB != 0 {
+		opts.DB = p.DB
+	}
+	if p.TLS.Enabled() {
+		cfg, err := p.TLS.ConfigFor(tlsHost(opts.Addr))
+		if err != nil {
+			return nil, err
+		}
+		opts.TLSConfig = cfg
+	}
+	p.cli = redis.NewClient(opts)
+	return p.cli, nil
+}
+
+func (p *RedisProvider) Open() ([]Content, error) {
+	out, err := p.read(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.sig = redisSignature(out)
+	p.mu.Unlock()
+	return out, nil
+}
+
+func (p *RedisProvider) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// read 读取 Key（或匹配 Key 的全部键），不存在的键被忽略。
+func (p *RedisProvider) read(ctx context.Context) ([]Content, error) {
+	cli, err := p.client()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+	keys := []string{p.Key}
+	if p.Pattern {
+		keys = keys[:0]
+		iter := cli.Scan(ctx, 0, p.Key, 100).Iterator()
+		for iter.Next(ctx) {
+			keys = append(keys, iter.Val())
+		}
+		if err := iter.Err(); err != nil {
+			return nil, fmt.Errorf("redis scan %s: %w", p.Key, err)
+		}
+		sort.Strings(keys)
+		keys = dedupSorted(keys)
+	}
+	out := make([]Content, 0, len(keys))
+	for _, key := range keys {
+		typ, err := cli.Type(ctx, key).Result()
+		if err != nil {
+			return nil, fmt.Errorf("redis type %s: %w", key, err)
+		}
+		var payload string
+		switch typ {
+		case "none":
+			continue
+		case "string":
+			if payload, err = cli.Get(ctx, key).Result(); errors.Is(err, redis.Nil) {
+				continue
+			}
+		case "hash":
+			var fields map[string]string
+			if fields, err = cli.HGetAll(ctx, key).Result(); err == nil {
+				payload, err = propertiesYAML(fields)
+			}
+		default:
+			return nil, fmt.Errorf("redis key %s: unsupported type %s", key, typ)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("redis key %s: %w", key, err)
+		}
+		out = append(out, Content{ID: key, Group: "redis", Payload: payload, Meta: map[string]string{"type": typ}})
+	}
+	return out, nil
+}
+
+// dedupSorted 去除有序切片中的重复项（SCAN 可能多次返回同一个键）。
+func dedupSorted(s []string) []string {
+	out := s[:0]
+	for i, v := range s {
+		if i == 0 || v != s[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// redisSignature 以键名与内容摘要标识一组内容。
+func redisSignature(contents []Content) string {
+	var b strings.Builder
+	for _, c := range contents {
+		fmt.Fprintf(&b, "%s@%x;", c.ID, sha256.Sum256([]byte(c.Payload)))
+	}
+	return b.String()
+}
+
+func (p *RedisProvider) Watch(onChange func() error) error {
+	return p.WatchContents(func([]Content) error { return onChange() })
+}
+
+// WatchContents 订阅 keyspace 通知或按周期轮询，内容变化时携带全部内容通知。
+func (p *RedisProvider) WatchContents(onChange func([]Content) error) error {
+	if _, err := p.client(); err != nil {
+		return err
+	}
+	// subscribe 与 poll 持续运行直到出错，之后退避重试，并重新判断监听方式
+	p.startWatch(0, p.RetryInterval, func(ctx context.Context) error {
+		if p.notificationsEnabled(ctx) {
+			return p.subscribe(ctx, onChange)
+		}
+		return p.poll(ctx, onChange)
+	})
+	return nil
+}
+
+// notificationsEnabled 判断服务端是否开启了覆盖 string 与 hash 写入的 keyspace 通知。
+func (p *RedisProvider) notificationsEnabled(ctx context.Context) bool {
+	switch p.Notify {
+	case "on":
+		return true
+	case "off":
+		return false
+	}
+	cli, err := p.client()
+	if err != nil {
+		return false
+	}
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+	cfg, err := cli.ConfigGet(ctx, "notify-keyspace-events").Result()
+	if err != nil {
+		return false
+	}
+	flags := cfg["notify-keyspace-events"]
+	if !strings.Contains(flags, "K") {
+		return false
+	}
+	return strings.Contains(flags, "A") || (strings.Contains(flags, "$") && strings.Contains(flags, "h") && strings.Contains(flags, "g"))
+}
+
+// subscribe 订阅 __keyspace@<db>__:<Key>，订阅成功后先检查一次以补上断线期间的变化。
+func (p *RedisProvider) subscribe(ctx context.Context, onChange func([]Content) error) error {
+	cli, err := p.client()
+	if err != nil {
+		return err
+	}
+	channel := fmt.Sprintf("__keyspace@%d__:%s", cli.Options().DB, p.Key)
+	ps := cli.PSubscribe(ctx, channel)
+	defer ps.Close()
+	if _, err := ps.Receive(ctx); err != nil {
+		return fmt.Errorf("redis subscribe %s: %w", channel, err)
+	}
+	if err := p.check(ctx, onChange); err != nil {
+		return err
+	}
+	p.setHealth(true, nil)
+	// 连接断开后 go-redis 会自动重新订阅，期间的通知会丢失；
+	// 因此除通知外，每次收到（重新）订阅确认也重新读取并比较
+	ch := ps.ChannelWithSubscriptions()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-ch:
+			if !ok {
+				return errors.New("redis subscription closed")
+			}
+		}
+		if err := p.check(ctx, onChange); err != nil {
+			return err
+		}
+		p.setHealth(true, nil)
+	}
+}
+
+// poll 按 Interval 重新读取，出错时返回。
+func (p *RedisProvider) poll(ctx context.Context, onChange func([]Content) error) error {
+	interval := p.Interval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+		if err := p.check(ctx, onChange); err != nil {
+			return err
+		}
+		p.setHealth(true, nil)
+	}
+}
+
+func (p *RedisProvider) check(ctx context.Context, onChange func([]Content) error) error {
+	out, err := p.read(ctx)
+	if err != nil {
+		return err
+	}
+	sig := redisSignature(out)
+	p.mu.Lock()
+	changed := sig != p.sig
+	p.sig = sig
+	p.mu.Unlock()
+	if changed {
+		_ = onChange(out)
+	}
+	return nil
+}
+
+// Close 停止监听并关闭连接。
+func (p *RedisProvider) Close() error {
+	p.stopWatch()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cli != nil {
+		err := p.cli.Close()
+		p.cli = nil
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package provider
+
+import (
+	"crypto/tls"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestRedis_OpenStringHashPattern(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.Set("config:app", "a: 1\n")
+	mr.HSet("config:db", "db.dsn", "x", "db.pool", "4")
+	mr.Set("other", "ignored: true\n")
+
+	cs, err := NewRedis(mr.Addr(), "config:app").Open()
+	if err != nil || len(cs) != 1 || cs[0].Payload != "a: 1\n" || cs[0].Meta["type"] != "string" {
+		t.Fatalf("string key: %+v %v", cs, err)
+	}
+
+	cs, err = NewRedisPattern(mr.Addr(), "config:*").Open()
+	if err != nil {
+		t.Fatalf("pattern: %v", err)
+	}
+	if len(cs) != 2 || cs[0].ID != "config:app" || cs[1].ID != "config:db" {
+		t.Fatalf("pattern contents: %+v", cs)
+	}
+	if !strings.Contains(cs[1].Payload, "pool: 4") || cs[1].Meta["type"] != "hash" {
+		t.Fatalf("hash payload: %+v", cs[1])
+	}
+
+	mr.Lpush("config:list", "x")
+	if _, err := NewRedisPattern(mr.Addr(), "config:*").Open(); err == nil || !strings.Contains(err.Error(), "unsupported type") {
+		t.Fatalf("want type error, got %v", err)
+	}
+}
+
+func TestRedis_Auth(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.RequireUserAuth("app", "s3cret")
+	mr.Set("config", "a: 1\n")
+
+	p := NewRedis(mr.Addr(), "config")
+	p.Username, p.Password = "app", "wrong"
+	if _, err := p.Open(); err == nil {
+		t.Fatalf("want auth error")
+	}
+	p.Close()
+
+	t.Setenv("REDIS_PASS", "s3cret")
+	p = NewRedis("redis://app@"+mr.Addr(), "config")
+	p.Password = "env:REDIS_PASS"
+	defer p.Close()
+	if cs, err := p.Open(); err != nil || cs[0].Payload != "a: 1\n" {
+		t.Fatalf("open: %+v %v", cs, err)
+	}
+}
+
+func TestRedis_TLS(t *testing.T) {
+	ca := newTestCert(t, "ca", nil, true, false)
+	srvCert := newTestCert(t, "server", ca, false, true)
+	mr, err := miniredis.RunTLS(&tls.Config{Certificates: []tls.Certificate{srvCert.tlsCert()}})
+	if err != nil {
+		t.Fatalf("run tls: %v", err)
+	}
+	defer mr.Close()
+	mr.Set("config", "a: 1\n")
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca.write(t, caFile, "", time.Now())
+	p := NewRedis(mr.Addr(), "config")
+	p.TLS = TLSOptions{CAFile: caFile}
+	defer p.Close()
+	if cs, err := p.Open(); err != nil || cs[0].Payload != "a: 1\n" {
+		t.Fatalf("open: %+v %v", cs, err)
+	}
+}
+
+func TestRedis_WatchNotifications(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.Set("config", "v: 1\n")
+
+	p := NewRedis(mr.Addr(), "config")
+	p.Notify = "on"
+	defer p.Close()
+	if _, err := p.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	got := make(chan []Content, 4)
+	if err := p.WatchContents(func(cs []Content) error { got <- cs; return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	// miniredis 不产生 keyspace 通知，这里按 Redis 的格式手动发布
+	deadline := time.Now().Add(3 * time.Second)
+	for mr.PubSubNumPat() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("not subscribed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mr.Set("config", "v: 2\n")
+	mr.Publish("__keyspace@0__:config", "set")
+	select {
+	case cs := <-got:
+		if cs[0].Payload != "v: 2\n" {
+			t.Fatalf("contents: %+v", cs)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("no change")
+	}
+}
+
+func TestRedis_WatchResubscribeRechecks(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.Set("config", "v: 1\n")
+
+	p := NewRedis(mr.Addr(), "config")
+	p.Notify = "on"
+	defer p.Close()
+	if _, err := p.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	got := make(chan []Content, 4)
+	if err := p.WatchContents(func(cs []Content) error { got <- cs; return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for mr.PubSubNumPat() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("not subscribed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// 断线期间的修改不会产生通知，重新订阅后应自行读取
+	mr.Close()
+	mr.Set("config", "v: 2\n")
+	if err := mr.Restart(); err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	select {
+	case cs := <-got:
+		if cs[0].Payload != "v: 2\n" {
+			t.Fatalf("contents: %+v", cs)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("change during reconnect was lost")
+	}
+}
+
+func TestRedis_WatchPollingFallback(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.HSet("config", "v", "1")
+
+	// miniredis 不支持 CONFIG GET，auto 模式回退到轮询
+	p := NewRedis(mr.Addr(), "config")
+	p.Interval = 20 * time.Millisecond
+	defer p.Close()
+	if _, err := p.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	got := make(chan []Content, 4)
+	if err := p.WatchContents(func(cs []Content) error { got <- cs; return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	mr.HSet("config", "v", "2")
+	select {
+	case cs := <-got:
+		if cs[0].Payload != "v: 2\n" {
+			t.Fatalf("contents: %+v", cs)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("no change")
+	}
+	if !p.Health().OK {
+		t.Fatalf("want healthy: %+v", p.Health())
+	}
+}
+
+func TestRedis_BackoffResetAfterRecovery(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.Set("config", "v: 1\n")
+
+	p := NewRedis(mr.Addr(), "config")
+	p.Notify = "off"
+	p.Interval = 10 * time.Millisecond
+	p.RetryInterval = 50 * time.Millisecond
+	defer p.Close()
+	if _, err := p.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	got := make(chan []Content, 4)
+	if err := p.WatchContents(func(cs []Content) error { got <- cs; return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	waitHealth := func(ok bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for p.Health().OK != ok {
+			if time.Now().After(deadline) {
+				t.Fatalf("health never became %v", ok)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// 连续失败使退避增长到 1.6s
+	mr.SetError("LOADING")
+	time.Sleep(1600 * time.Millisecond)
+	mr.SetError("")
+	waitHealth(true)
+
+	// 恢复后再次短暂故障，应从 RetryInterval 重新开始退避
+	mr.SetError("LOADING")
+	waitHealth(false)
+	mr.SetError("")
+	mr.Set("config", "v: 2\n")
+	select {
+	case cs := <-got:
+		if cs[0].Payload != "v: 2\n" {
+			t.Fatalf("contents: %+v", cs)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatalf("backoff not reset after recovery")
+	}
+}
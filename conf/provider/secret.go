@@ -0,0 +1,31 @@
+package provider
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ResolveSecret 解析凭据：file:/path 读取文件内容（去掉首尾空白），env:NAME 读取环境变量，
+// literal:VALUE 原样返回 VALUE（用于本身以 file: 或 env: 开头的值），其他值原样返回。
+// 便于避免在命令行中直接传递密码。
+func ResolveSecret(v string) (string, error) {
+	switch {
+	case strings.HasPrefix(v, "literal:"):
+		return strings.TrimPrefix(v, "literal:"), nil
+	case strings.HasPrefix(v, "file:"):
+		b, err := os.ReadFile(strings.TrimPrefix(v, "file:"))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(b)), nil
+	case strings.HasPrefix(v, "env:"):
+		name := strings.TrimPrefix(v, "env:")
+		s, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("env %s not set", name)
+		}
+		return s, nil
+	}
+	return v, nil
+}
@@ -0,0 +1,39 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveSecret(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "pass")
+	if err := os.WriteFile(f, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("NACOS_TEST_PASS", "fromenv")
+	cases := map[string]string{"plain": "plain", "file:" + f: "s3cret", "env:NACOS_TEST_PASS": "fromenv", "": "",
+		"literal:env:NACOS_TEST_PASS": "env:NACOS_TEST_PASS", "literal:file:x": "file:x", "literal:literal:x": "literal:x"}
+	for in, want := range cases {
+		got, err := ResolveSecret(in)
+		if err != nil || got != want {
+			t.Fatalf("%q: got %q %v", in, got, err)
+		}
+	}
+	for _, bad := range []string{"file:" + f + ".missing", "env:NACOS_TEST_UNSET"} {
+		if _, err := ResolveSecret(bad); err == nil {
+			t.Fatalf("%q: want error", bad)
+		}
+	}
+}
+
+func TestNacos_SecretError(t *testing.T) {
+	p := NewNacos([]string{"127.0.0.1:8848"}, "", "DEFAULT_GROUP", "x")
+	p.Username = "nacos"
+	p.Password = "env:NACOS_TEST_UNSET"
+	err := p.ensureClient()
+	if err == nil || !strings.Contains(err.Error(), "nacos password") {
+		t.Fatalf("want password error, got %v", err)
+	}
+}
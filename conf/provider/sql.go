@@ -0,0 +1,311 @@
+package provider
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SQLProvider 通过 database/sql 读取配置表中 (id, group, format, payload, version) 的行，
+// 每行一个 Content，按 id 顺序合并；按周期查询 version 列（也可以是 updated_at 等列）判断是否变化。
+// format 为 yaml、json 或 properties，空值视为 yaml。
+type SQLProvider struct {
+	DB     *sql.DB // 已打开的连接池，由调用方关闭；为空时使用 Driver 与 DSN 打开
+	Driver string  // 驱动名，同时决定占位符与标识符的引用方式：postgres/pgx 使用 $1，mysql 使用反引号
+	DSN    string  // 支持 file:/path 与 env:NAME，见 ResolveSecret
+
+	Table         string // 默认 config_items，可带 schema，例如 app.config_items
+	Group         string // 只读取 group 列等于该值的行，空表示全部
+	VersionColumn string // 用于检测变化的列，默认 version
+	Timeout       time.Duration
+
+	Interval      time.Duration // 轮询间隔，0 表示不监听
+	RetryInterval time.Duration // 查询失败后首次重试的间隔，默认 1s
+
+	watcher
+	mu    sync.Mutex
+	db    *sql.DB
+	owned bool   // db 由 Provider 打开，Close 时一并关闭
+	sig   string // 最近一次读取的 id 与 version
+}
+
+func NewSQL(driver, dsn, table, group string) *SQLProvider {
+	return &SQLProvider{Driver: driver, DSN: dsn, Table: table, Group: group, VersionColumn: "version",
+		Timeout: 10 * time.Second, RetryInterval: time.Second}
+}
+
+// NewSQLWithDB 使用已有的连接池创建 SQLProvider，driver 仅用于选择 SQL 方言。
+func NewSQLWithDB(db *sql.DB, driver, table, group string) *SQLProvider {
+	p := NewSQL(driver, "", table, group)
+	p.DB = db
+	return p
+}
+
+func (p *SQLProvider) conn() (*sql.DB, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.DB != nil {
+		return p.DB, nil
+	}
+	if p.db != nil {
+		return p.db, nil
+	}
+	if p.Driver == "" || p.DSN == "" {
+		return nil, errors.New("sql driver and dsn are required")
+	}
+	dsn, err := ResolveSecret(p.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("sql dsn: %w", err)
+	}
+	db, err := sql.Open(p.Driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	p.db, p.owned = db, true
+	return db, nil
+}
+
+var sqlIdent = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// quote 按方言引用标识符，带 schema 的表名逐段引用；仅允许字母、数字与下划线。
+func (p *SQLProvider) quote(name string) (string, error) {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if !sqlIdent.MatchString(part) {
+			return "", fmt.Errorf("invalid sql identifier %q", name)
+		}
+		if p.Driver == "mysql" {
+			parts[i] = "`" + part + "`"
+		} else {
+			parts[i] = `"` + part + `"`
+		}
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// query 生成按 id 排序的查询，columns 为待读取的列（未引用）。
+func (p *SQLProvider) query(columns ...string) (string, []any, error) {
+	table := p.Table
+	if table == "" {
+		table = "config_items"
+	}
+	t, err := p.quote(table)
+	if err != nil {
+		return "", nil, err
+	}
+	cols := make([]string, len(columns))
+	for i, c := range columns {
+		if cols[i], err = p.quote(c); err != nil {
+			return "", nil, err
+		}
+	}
+	q := "SELECT " + strings.Join(cols, ", ") + " FROM " + t
+	var args []any
+	if p.Group != "" {
+		g, _ := p.quote("group")
+		placeholder := "?"
+		if p.Driver == "postgres" || p.Driver == "pgx" {
+			placeholder = "$1"
+		}
+		q += " WHERE " + g + " = " + placeholder
+		args = append(args, p.Group)
+	}
+	id, _ := p.quote("id")
+	return q + " ORDER BY " + id, args, nil
+}
+
+func (p *SQLProvider) versionColumn() string {
+	if p.VersionColumn == "" {
+		return "version"
+	}
+	return p.VersionColumn
+}
+
+func (p *SQLProvider) Open() ([]Content, error) {
+	out, err := p.read(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.sig = sqlSignature(out)
+	p.mu.Unlock()
+	return out, nil
+}
+
+func (p *SQLProvider) read(ctx context.Context) ([]Content, error) {
+	db, err := p.conn()
+	if err != nil {
+		return nil, err
+	}
+	q, args, err := p.query("id", "group", "format", "payload", p.versionColumn())
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+	rows, err := db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sql query %s: %w", p.Table, err)
+	}
+	defer rows.Close()
+	var out []Content
+	for rows.Next() {
+		var id, group, payload string
+		var format, version sql.NullString
+		if err := rows.Scan(&id, &group, &format, &payload, &version); err != nil {
+			return nil, fmt.Errorf("sql query %s: %w", p.Table, err)
+		}
+		doc, err := sqlPayload(format.String, payload)
+		if err != nil {
+			return nil, fmt.Errorf("sql row %s: %w", id, err)
+		}
+		c := Content{ID: id, Group: group, Payload: doc, Meta: map[string]string{"version": version.String}}
+		if format.String != "" {
+			c.Meta["format"] = format.String
+		}
+		c.Version, _ = strconv.ParseInt(version.String, 10, 64)
+		out = append(out, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sql query %s: %w", p.Table, err)
+	}
+	return out, nil
+}
+
+func (p *SQLProvider) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// sqlPayload 将行内容按 format 转换为 YAML 文档。
+func sqlPayload(format, payload string) (string, error) {
+	switch strings.ToLower(format) {
+	case "", "yaml", "yml", "json":
+		// JSON 是 YAML 的子集，直接交给 YAML 解析
+		return payload, nil
+	case "properties":
+		props := map[string]string{}
+		sc := bufio.NewScanner(strings.NewReader(payload))
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if line == "" || line[0] == '#' || line[0] == '!' {
+				continue
+			}
+			k, v, ok := strings.Cut(line, "=")
+			if !ok {
+				k, v, _ = strings.Cut(line, ":")
+			}
+			props[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+		return propertiesYAML(props)
+	default:
+		return "", fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// sqlSignature 以 id 与 version 标识一组行。
+func sqlSignature(contents []Content) string {
+	var b strings.Builder
+	for _, c := range contents {
+		fmt.Fprintf(&b, "%s@%s;", c.ID, c.Meta["version"])
+	}
+	return b.String()
+}
+
+// versions 只查询 id 与 version 列，返回当前的签名。
+func (p *SQLProvider) versions(ctx context.Context) (string, error) {
+	db, err := p.conn()
+	if err != nil {
+		return "", err
+	}
+	q, args, err := p.query("id", p.versionColumn())
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+	rows, err := db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return "", fmt.Errorf("sql query %s: %w", p.Table, err)
+	}
+	defer rows.Close()
+	var b strings.Builder
+	for rows.Next() {
+		var id string
+		var version sql.NullString
+		if err := rows.Scan(&id, &version); err != nil {
+			return "", fmt.Errorf("sql query %s: %w", p.Table, err)
+		}
+		fmt.Fprintf(&b, "%s@%s;", id, version.String)
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("sql query %s: %w", p.Table, err)
+	}
+	return b.String(), nil
+}
+
+func (p *SQLProvider) Watch(onChange func() error) error {
+	return p.WatchContents(func([]Content) error { return onChange() })
+}
+
+// WatchContents 按 Interval 查询 version 列，有行新增、删除或 version 变化时重新读取并通知。
+func (p *SQLProvider) WatchContents(onChange func([]Content) error) error {
+	if p.Interval <= 0 {
+		return nil
+	}
+	if _, err := p.conn(); err != nil {
+		return err
+	}
+	p.startWatch(p.Interval, p.RetryInterval, func(ctx context.Context) error {
+		changed, out, err := p.check(ctx)
+		if changed {
+			_ = onChange(out)
+		}
+		return err
+	})
+	return nil
+}
+
+func (p *SQLProvider) check(ctx context.Context) (bool, []Content, error) {
+	sig, err := p.versions(ctx)
+	if err != nil {
+		return false, nil, err
+	}
+	p.mu.Lock()
+	last := p.sig
+	p.mu.Unlock()
+	if sig == last {
+		return false, nil, nil
+	}
+	out, err := p.read(ctx)
+	if err != nil {
+		return false, nil, err
+	}
+	p.mu.Lock()
+	p.sig = sqlSignature(out)
+	p.mu.Unlock()
+	return true, out, nil
+}
+
+// Close 停止轮询，并关闭由 Provider 自行打开的连接池。
+func (p *SQLProvider) Close() error {
+	p.stopWatch()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.owned && p.db != nil {
+		err := p.db.Close()
+		p.db, p.owned = nil, false
+		return err
+	}
+	return nil
+}
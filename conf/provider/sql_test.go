@@ -0,0 +1,124 @@
+package provider
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func newSQLiteDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "config.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	_, err = db.Exec(`CREATE TABLE config_items (
+		id TEXT PRIMARY KEY, "group" TEXT NOT NULL, format TEXT, payload TEXT NOT NULL, version INTEGER NOT NULL)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestSQL_OpenRows(t *testing.T) {
+	db := newSQLiteDB(t)
+	rows := [][]any{
+		{"20-db", "demo", "json", `{"db": {"dsn": "x"}}`, 3},
+		{"10-base", "demo", "yaml", "server:\n  bind: ':8080'\n", 1},
+		{"30-flags", "demo", "properties", "feature.enabled=true\n# comment\nfeature.limit = 5\n", 2},
+		{"00-other", "other", nil, "a: 1\n", 1},
+	}
+	for _, r := range rows {
+		if _, err := db.Exec(`INSERT INTO config_items VALUES (?, ?, ?, ?, ?)`, r...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := NewSQLWithDB(db, "sqlite", "", "demo")
+	cs, err := p.Open()
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if len(cs) != 3 || cs[0].ID != "10-base" || cs[1].ID != "20-db" || cs[2].ID != "30-flags" {
+		t.Fatalf("order: %+v", cs)
+	}
+	if cs[1].Version != 3 || cs[1].Meta["format"] != "json" || cs[1].Group != "demo" {
+		t.Fatalf("row: %+v", cs[1])
+	}
+	if !strings.Contains(cs[2].Payload, "enabled: true") || !strings.Contains(cs[2].Payload, "limit: 5") {
+		t.Fatalf("properties payload: %q", cs[2].Payload)
+	}
+
+	all, err := NewSQLWithDB(db, "sqlite", "", "").Open()
+	if err != nil || len(all) != 4 {
+		t.Fatalf("all rows: %d %v", len(all), err)
+	}
+}
+
+func TestSQL_Errors(t *testing.T) {
+	db := newSQLiteDB(t)
+	if _, err := NewSQLWithDB(db, "sqlite", "config_items; DROP TABLE x", "").Open(); err == nil || !strings.Contains(err.Error(), "invalid sql identifier") {
+		t.Fatalf("want identifier error, got %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO config_items VALUES ('a', 'g', 'toml', 'a = 1', 1)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSQLWithDB(db, "sqlite", "", "g").Open(); err == nil || !strings.Contains(err.Error(), "unsupported format") {
+		t.Fatalf("want format error, got %v", err)
+	}
+}
+
+func TestSQL_WatchVersion(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "watch.db")
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE settings (id TEXT, "group" TEXT, format TEXT, payload TEXT, updated_at TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO settings VALUES ('a', 'demo', 'yaml', 'v: 1', '2024-01-01T00:00:00Z')`); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewSQL("sqlite", dsn, "settings", "demo")
+	p.VersionColumn = "updated_at"
+	p.Interval = 20 * time.Millisecond
+	defer p.Close()
+	if _, err := p.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	got := make(chan []Content, 4)
+	if err := p.WatchContents(func(cs []Content) error { got <- cs; return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	// payload 变化但 updated_at 未变时不触发
+	if _, err := db.Exec(`UPDATE settings SET payload = 'v: 0'`); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	select {
+	case cs := <-got:
+		t.Fatalf("unexpected change: %+v", cs)
+	default:
+	}
+	if _, err := db.Exec(`UPDATE settings SET payload = 'v: 2', updated_at = '2024-01-02T00:00:00Z'`); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case cs := <-got:
+		if len(cs) != 1 || cs[0].Payload != "v: 2" || cs[0].Meta["version"] != "2024-01-02T00:00:00Z" {
+			t.Fatalf("contents: %+v", cs)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("no change")
+	}
+	if !p.Health().OK {
+		t.Fatalf("want healthy: %+v", p.Health())
+	}
+}
@@ -0,0 +1,180 @@
+package provider
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TLSOptions 描述客户端 TLS 设置。证书文件在握手时按修改时间重新读取，
+// 因此轮换 CA 或客户端证书无需重启进程。
+type TLSOptions struct {
+	CAFile             string // 校验服务端的 CA，为空时使用系统根证书
+	CertFile           string // 客户端证书（双向 TLS）
+	KeyFile            string // 客户端私钥（双向 TLS）
+	ServerName         string // 覆盖校验时使用的服务端名称
+	InsecureSkipVerify bool   // 跳过服务端校验，仅用于开发环境
+}
+
+// Enabled 判断是否设置了任意 TLS 选项。
+func (o *TLSOptions) Enabled() bool {
+	return o != nil && (o.CAFile != "" || o.CertFile != "" || o.KeyFile != "" || o.ServerName != "" || o.InsecureSkipVerify)
+}
+
+// Config 构建 *tls.Config，等价于 ConfigFor("")，适用于通过域名连接的服务端。
+func (o *TLSOptions) Config() (*tls.Config, error) {
+	return o.ConfigFor("")
+}
+
+// ConfigFor 构建连接 host（域名或 IP）的 *tls.Config。首次构建时即读取一次证书，以便尽早暴露路径或格式错误。
+// 设置了 CAFile 时由 VerifyConnection 按最新的 CA 校验服务端证书，校验的名称依次取 ServerName、
+// 握手时发送的 SNI 与 host；crypto/tls 连接 IP 时不发送 SNI，此时没有 host 则握手失败。
+func (o *TLSOptions) ConfigFor(host string) (*tls.Config, error) {
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		return nil, errors.New("tls: cert and key must be set together")
+	}
+	r := &certReloader{opts: *o, host: host}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: o.ServerName,
+	}
+	if o.CertFile != "" {
+		cfg.GetClientCertificate = r.clientCertificate
+	}
+	if o.InsecureSkipVerify {
+		cfg.InsecureSkipVerify = true
+	} else if o.CAFile != "" {
+		// 由 VerifyConnection 使用最新的 CA 校验，标准校验需关闭以免使用固定的 RootCAs
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyConnection = r.verifyConnection
+	}
+	return cfg, nil
+}
+
+// certReloader 缓存证书与 CA，文件修改时间变化时重新加载。
+type certReloader struct {
+	opts TLSOptions
+	host string // 连接的目标主机，握手没有 SNI 时用于校验
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	certMod time.Time
+	keyMod  time.Time
+	pool    *x509.CertPool
+	caMod   time.Time
+}
+
+func (r *certReloader) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.opts.CertFile != "" {
+		certMod, err := modTime(r.opts.CertFile)
+		if err != nil {
+			return err
+		}
+		keyMod, err := modTime(r.opts.KeyFile)
+		if err != nil {
+			return err
+		}
+		if r.cert == nil || !certMod.Equal(r.certMod) || !keyMod.Equal(r.keyMod) {
+			cert, err := tls.LoadX509KeyPair(r.opts.CertFile, r.opts.KeyFile)
+			if err != nil {
+				return fmt.Errorf("tls: load client cert: %w", err)
+			}
+			r.cert, r.certMod, r.keyMod = &cert, certMod, keyMod
+		}
+	}
+	if r.opts.CAFile != "" {
+		caMod, err := modTime(r.opts.CAFile)
+		if err != nil {
+			return err
+		}
+		if r.pool == nil || !caMod.Equal(r.caMod) {
+			b, err := os.ReadFile(r.opts.CAFile)
+			if err != nil {
+				return fmt.Errorf("tls: read ca: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(b) {
+				return fmt.Errorf("tls: no certificates in %s", r.opts.CAFile)
+			}
+			r.pool, r.caMod = pool, caMod
+		}
+	}
+	return nil
+}
+
+func (r *certReloader) clientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	if err := r.reload(); err != nil {
+		// 重新加载失败时继续使用上一次成功加载的证书
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.cert == nil {
+			return nil, err
+		}
+		return r.cert, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cert, nil
+}
+
+func (r *certReloader) verifyConnection(cs tls.ConnectionState) error {
+	_ = r.reload()
+	r.mu.Lock()
+	pool := r.pool
+	r.mu.Unlock()
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("tls: no server certificate")
+	}
+	name := r.opts.ServerName
+	if name == "" {
+		name = cs.ServerName
+	}
+	if name == "" {
+		name = r.host
+	}
+	if name == "" {
+		return errors.New("tls: no server name to verify the certificate against, set ServerName")
+	}
+	opts := x509.VerifyOptions{Roots: pool, DNSName: name, Intermediates: x509.NewCertPool()}
+	for _, c := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(c)
+	}
+	_, err := cs.PeerCertificates[0].Verify(opts)
+	return err
+}
+
+// tlsHost 从 URL 或 host:port 中取出主机名（域名或 IP），作为 ConfigFor 的参数。
+func tlsHost(addr string) string {
+	if i := strings.Index(addr, "://"); i >= 0 {
+		addr = addr[i+3:]
+	}
+	if i := strings.IndexAny(addr, "/?#"); i >= 0 {
+		addr = addr[:i]
+	}
+	if i := strings.LastIndex(addr, "@"); i >= 0 {
+		addr = addr[i+1:]
+	}
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return strings.Trim(addr, "[]")
+}
+
+func modTime(path string) (time.Time, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fi.ModTime(), nil
+}
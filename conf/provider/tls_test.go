@@ -0,0 +1,256 @@
+package provider
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+var testSerial int64
+
+func newTestCert(t *testing.T, cn string, parent *testCert, isCA, server bool) *testCert {
+	t.Helper()
+	if server {
+		return newTestServerCert(t, cn, parent, "localhost", "127.0.0.1")
+	}
+	return newTestCertHosts(t, cn, parent, isCA, false, nil)
+}
+
+// newTestServerCert 签发对 hosts（域名或 IP）有效的服务端证书。
+func newTestServerCert(t *testing.T, cn string, parent *testCert, hosts ...string) *testCert {
+	t.Helper()
+	return newTestCertHosts(t, cn, parent, false, true, hosts)
+}
+
+func newTestCertHosts(t *testing.T, cn string, parent *testCert, isCA, server bool, hosts []string) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("key: %v", err)
+	}
+	testSerial++
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(testSerial),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	if server {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		for _, h := range hosts {
+			if ip := net.ParseIP(h); ip != nil {
+				tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+			} else {
+				tmpl.DNSNames = append(tmpl.DNSNames, h)
+			}
+		}
+	} else if !isCA {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	}
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("create cert: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+func (c *testCert) tlsCert() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+// write 写入证书与私钥，并推进修改时间以确保被识别为新文件。
+func (c *testCert) write(t *testing.T, certPath, keyPath string, mod time.Time) {
+	t.Helper()
+	writePEM(t, certPath, "CERTIFICATE", c.der, mod)
+	if keyPath != "" {
+		b, err := x509.MarshalECPrivateKey(c.key)
+		if err != nil {
+			t.Fatalf("marshal key: %v", err)
+		}
+		writePEM(t, keyPath, "EC PRIVATE KEY", b, mod)
+	}
+}
+
+func writePEM(t *testing.T, path, typ string, der []byte, mod time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.Chtimes(path, mod, mod); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+}
+
+func TestTLSOptions_MutualAndReload(t *testing.T) {
+	ca := newTestCert(t, "ca", nil, true, false)
+	srvCert := newTestCert(t, "server", ca, false, true)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	seen := make(chan string, 4)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen <- r.TLS.PeerCertificates[0].Subject.CommonName
+	}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{srvCert.tlsCert()}, ClientCAs: pool, ClientAuth: tls.RequireAndVerifyClientCert}
+	srv.StartTLS()
+	defer srv.Close()
+
+	dir := t.TempDir()
+	opts := &TLSOptions{
+		CAFile:   filepath.Join(dir, "ca.pem"),
+		CertFile: filepath.Join(dir, "client.pem"),
+		KeyFile:  filepath.Join(dir, "client-key.pem"),
+	}
+	now := time.Now()
+	ca.write(t, opts.CAFile, "", now)
+	newTestCert(t, "client-1", ca, false, false).write(t, opts.CertFile, opts.KeyFile, now)
+	cfg, err := opts.ConfigFor("127.0.0.1")
+	if err != nil {
+		t.Fatalf("config: %v", err)
+	}
+	get := func() string {
+		cli := &http.Client{Transport: &http.Transport{TLSClientConfig: cfg, DisableKeepAlives: true}}
+		resp, err := cli.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		resp.Body.Close()
+		return <-seen
+	}
+	if cn := get(); cn != "client-1" {
+		t.Fatalf("bad client cert: %s", cn)
+	}
+	newTestCert(t, "client-2", ca, false, false).write(t, opts.CertFile, opts.KeyFile, now.Add(time.Second))
+	if cn := get(); cn != "client-2" {
+		t.Fatalf("cert not reloaded: %s", cn)
+	}
+}
+
+func TestTLSOptions_CAReload(t *testing.T) {
+	ca1 := newTestCert(t, "ca-1", nil, true, false)
+	ca2 := newTestCert(t, "ca-2", nil, true, false)
+	srvCert := newTestCert(t, "server", ca2, false, true)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{srvCert.tlsCert()}}
+	srv.StartTLS()
+	defer srv.Close()
+
+	dir := t.TempDir()
+	opts := &TLSOptions{CAFile: filepath.Join(dir, "ca.pem")}
+	now := time.Now()
+	ca1.write(t, opts.CAFile, "", now)
+	cfg, err := opts.ConfigFor("127.0.0.1")
+	if err != nil {
+		t.Fatalf("config: %v", err)
+	}
+	cli := &http.Client{Transport: &http.Transport{TLSClientConfig: cfg, DisableKeepAlives: true}}
+	if _, err := cli.Get(srv.URL); err == nil {
+		t.Fatalf("want verify error with wrong ca")
+	}
+	ca2.write(t, opts.CAFile, "", now.Add(time.Second))
+	resp, err := cli.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("get after ca reload: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestTLSOptions_HostnameMismatch(t *testing.T) {
+	ca := newTestCert(t, "ca", nil, true, false)
+	// 证书由同一个 CA 签发，但不包含实际连接的 127.0.0.1
+	srvCert := newTestServerCert(t, "server", ca, "other.example", "10.0.0.9")
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{srvCert.tlsCert()}}
+	srv.StartTLS()
+	defer srv.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca.write(t, caFile, "", time.Now())
+	get := func(opts TLSOptions, host string) error {
+		cfg, err := opts.ConfigFor(host)
+		if err != nil {
+			t.Fatalf("config: %v", err)
+		}
+		cli := &http.Client{Transport: &http.Transport{TLSClientConfig: cfg, DisableKeepAlives: true}}
+		resp, err := cli.Get(srv.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+	if err := get(TLSOptions{CAFile: caFile}, "127.0.0.1"); err == nil || !strings.Contains(err.Error(), "127.0.0.1") {
+		t.Fatalf("want ip mismatch, got %v", err)
+	}
+	if err := get(TLSOptions{CAFile: caFile, ServerName: "wrong.example"}, "127.0.0.1"); err == nil {
+		t.Fatalf("want name mismatch")
+	}
+	// 连接 IP 时没有 SNI，也没有可校验的名称
+	if err := get(TLSOptions{CAFile: caFile}, ""); err == nil || !strings.Contains(err.Error(), "no server name") {
+		t.Fatalf("want missing name error, got %v", err)
+	}
+	if err := get(TLSOptions{CAFile: caFile, ServerName: "other.example"}, "127.0.0.1"); err != nil {
+		t.Fatalf("server name override: %v", err)
+	}
+
+	if _, err := etcdTLSConfig(EtcdClientOptions{Endpoints: []string{"10.0.0.1:2379", "10.0.0.2:2379"},
+		TLS: TLSOptions{CAFile: caFile}}); err == nil {
+		t.Fatalf("want error for multiple ip endpoints without server name")
+	}
+	if _, err := etcdTLSConfig(EtcdClientOptions{Endpoints: []string{"https://etcd-1:2379", "https://etcd-2:2379"},
+		TLS: TLSOptions{CAFile: caFile}}); err != nil {
+		t.Fatalf("dns endpoints: %v", err)
+	}
+}
+
+func TestTLSHost(t *testing.T) {
+	for in, want := range map[string]string{
+		"https://vault.local:8200/v1": "vault.local",
+		"127.0.0.1:2379":              "127.0.0.1",
+		"rediss://u:p@[::1]:6380/0":   "::1",
+		"example.com":                 "example.com",
+		"":                            "",
+	} {
+		if got := tlsHost(in); got != want {
+			t.Fatalf("tlsHost(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestTLSOptions_Errors(t *testing.T) {
+	if _, err := (&TLSOptions{CertFile: "a.pem"}).Config(); err == nil {
+		t.Fatalf("want error for cert without key")
+	}
+	if _, err := (&TLSOptions{CAFile: "/not-exist-ca.pem"}).Config(); err == nil {
+		t.Fatalf("want error for missing ca")
+	}
+	if (&TLSOptions{}).Enabled() || !(&TLSOptions{InsecureSkipVerify: true}).Enabled() {
+		t.Fatalf("bad enabled")
+	}
+}
@@ -0,0 +1,384 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// VaultPath 为一个 KV v2 secret 的路径及其在配置中的挂载位置（以 "." 分隔的层级，空表示根）。
+type VaultPath struct {
+	Path    string
+	MountAt string
+}
+
+// ParseVaultPaths 解析 "path[=subtree],..."，未指定 subtree 时使用 def。
+func ParseVaultPaths(s, def string) []VaultPath {
+	var out []VaultPath
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		path, at, ok := strings.Cut(item, "=")
+		if !ok {
+			at = def
+		}
+		out = append(out, VaultPath{Path: strings.Trim(strings.TrimSpace(path), "/"), MountAt: strings.TrimSpace(at)})
+	}
+	return out
+}
+
+// VaultProvider 从 Vault KV v2 读取 secret，每个路径一个 Content，secret 的字段挂载到 MountAt 指定的层级下。
+// 认证使用 Token，或 RoleID/SecretID 的 AppRole 登录；AppRole 取得的令牌在有效期的 2/3 处续期，
+// 无法续期时重新登录，续期后重新读取。按 Interval 轮询 secret 的版本。
+type VaultProvider struct {
+	Address   string // 例如 https://vault:8200
+	Token     string // 以下凭据均支持 file:/path 与 env:NAME，见 ResolveSecret
+	RoleID    string
+	SecretID  string
+	AuthMount string // AppRole 的挂载路径，默认 approle
+	Namespace string // Vault 企业版命名空间
+	Mount     string // KV v2 引擎的挂载路径，默认 secret
+	Paths     []VaultPath
+	TLS       TLSOptions
+	Timeout   time.Duration
+
+	Interval      time.Duration // 版本轮询间隔，0 表示只在令牌续期时重新读取
+	RetryInterval time.Duration // 读取或续期失败后首次重试的间隔，默认 1s
+
+	watcher
+	mu        sync.Mutex
+	cli       *http.Client
+	token     string    // AppRole 登录得到的令牌
+	renewable bool      // 令牌是否可续期
+	renewAt   time.Time // 下一次续期（或重新登录）的时间，零值表示无需续期
+	sig       string    // 最近一次读取的路径与版本
+}
+
+func NewVault(address string, paths ...VaultPath) *VaultProvider {
+	return &VaultProvider{Address: address, Paths: paths, AuthMount: "approle", Mount: "secret",
+		Timeout: 10 * time.Second, RetryInterval: time.Second}
+}
+
+func (p *VaultProvider) client() (*http.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cli != nil {
+		return p.cli, nil
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	if p.TLS.Enabled() {
+		cfg, err := p.TLS.ConfigFor(tlsHost(p.Address))
+		if err != nil {
+			return nil, err
+		}
+		tr.TLSClientConfig = cfg
+	}
+	p.cli = &http.Client{Transport: tr}
+	return p.cli, nil
+}
+
+// vaultError 为 Vault 返回的错误；读取时遇到 403 会丢弃 AppRole 令牌重新登录。
+type vaultError struct {
+	Op     string
+	Status int
+	Errors []string
+}
+
+func (e *vaultError) Error() string {
+	return fmt.Sprintf("vault %s: %d %s: %s", e.Op, e.Status, http.StatusText(e.Status), strings.Join(e.Errors, "; "))
+}
+
+// do 发起请求并将 2xx 响应体解码到 out；token 为空时不携带令牌。
+func (p *VaultProvider) do(ctx context.Context, method, path, token string, in, out any) error {
+	if p.Address == "" {
+		return errors.New("vault address is empty")
+	}
+	cli, err := p.client()
+	if err != nil {
+		return err
+	}
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(p.Address, "/")+"/v1/"+path, body)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if p.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.Namespace)
+	}
+	resp, err := cli.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		var e struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+		return &vaultError{Op: path, Status: resp.StatusCode, Errors: e.Errors}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("vault %s: %w", path, err)
+	}
+	return nil
+}
+
+type vaultAuth struct {
+	Auth struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int64  `json:"lease_duration"`
+		Renewable     bool   `json:"renewable"`
+	} `json:"auth"`
+}
+
+// authToken 返回请求使用的令牌：优先使用 Token，否则使用（必要时登录取得的）AppRole 令牌。
+func (p *VaultProvider) authToken(ctx context.Context) (string, error) {
+	if p.Token != "" {
+		token, err := ResolveSecret(p.Token)
+		if err != nil {
+			return "", fmt.Errorf("vault token: %w", err)
+		}
+		return token, nil
+	}
+	if p.RoleID == "" {
+		return "", errors.New("vault token or approle role id is required")
+	}
+	p.mu.Lock()
+	token := p.token
+	p.mu.Unlock()
+	if token != "" {
+		return token, nil
+	}
+	return p.login(ctx)
+}
+
+// login 使用 AppRole 登录并记录令牌的续期时间。
+func (p *VaultProvider) login(ctx context.Context) (string, error) {
+	roleID, err := ResolveSecret(p.RoleID)
+	if err != nil {
+		return "", fmt.Errorf("vault role id: %w", err)
+	}
+	secretID, err := ResolveSecret(p.SecretID)
+	if err != nil {
+		return "", fmt.Errorf("vault secret id: %w", err)
+	}
+	mount := p.AuthMount
+	if mount == "" {
+		mount = "approle"
+	}
+	var out vaultAuth
+	in := map[string]string{"role_id": roleID, "secret_id": secretID}
+	if err := p.do(ctx, http.MethodPost, "auth/"+strings.Trim(mount, "/")+"/login", "", in, &out); err != nil {
+		return "", err
+	}
+	p.setToken(out)
+	return out.Auth.ClientToken, nil
+}
+
+func (p *VaultProvider) setToken(a vaultAuth) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.token, p.renewable = a.Auth.ClientToken, a.Auth.Renewable
+	p.renewAt = time.Time{}
+	if ttl := time.Duration(a.Auth.LeaseDuration) * time.Second; ttl > 0 {
+		p.renewAt = time.Now().Add(ttl * 2 / 3)
+	}
+}
+
+// renew 续期 AppRole 令牌，令牌不可续期或续期失败时重新登录。
+func (p *VaultProvider) renew(ctx context.Context) error {
+	p.mu.Lock()
+	token, renewable := p.token, p.renewable
+	p.mu.Unlock()
+	if token != "" && renewable {
+		var out vaultAuth
+		if err := p.do(ctx, http.MethodPost, "auth/token/renew-self", token, map[string]any{}, &out); err == nil {
+			if out.Auth.ClientToken == "" {
+				out.Auth.ClientToken = token
+			}
+			p.setToken(out)
+			return nil
+		}
+	}
+	p.mu.Lock()
+	p.token = ""
+	p.mu.Unlock()
+	_, err := p.login(ctx)
+	return err
+}
+
+func (p *VaultProvider) Open() ([]Content, error) {
+	out, err := p.read(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.sig = vaultSignature(out)
+	p.mu.Unlock()
+	return out, nil
+}
+
+func (p *VaultProvider) mount() string {
+	if p.Mount == "" {
+		return "secret"
+	}
+	return strings.Trim(p.Mount, "/")
+}
+
+// read 按顺序读取全部路径；令牌失效（403）时对 AppRole 重新登录后重试一次。
+func (p *VaultProvider) read(ctx context.Context) ([]Content, error) {
+	if len(p.Paths) == 0 {
+		return nil, errors.New("vault paths are empty")
+	}
+	out, err := p.readOnce(ctx)
+	var ve *vaultError
+	if err != nil && p.Token == "" && errors.As(err, &ve) && ve.Status == http.StatusForbidden {
+		p.mu.Lock()
+		p.token = ""
+		p.mu.Unlock()
+		out, err = p.readOnce(ctx)
+	}
+	return out, err
+}
+
+func (p *VaultProvider) readOnce(ctx context.Context) ([]Content, error) {
+	token, err := p.authToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Content, 0, len(p.Paths))
+	for _, vp := range p.Paths {
+		var body struct {
+			Data struct {
+				Data     map[string]any `json:"data"`
+				Metadata struct {
+					Version int64 `json:"version"`
+				} `json:"metadata"`
+			} `json:"data"`
+		}
+		if err := p.do(ctx, http.MethodGet, p.mount()+"/data/"+vp.Path, token, nil, &body); err != nil {
+			return nil, err
+		}
+		doc := body.Data.Data
+		if vp.MountAt != "" {
+			doc = map[string]any{}
+			if err := setPath(doc, strings.Split(vp.MountAt, "."), body.Data.Data); err != nil {
+				return nil, fmt.Errorf("vault %s: mount at %s: %w", vp.Path, vp.MountAt, err)
+			}
+		}
+		b, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		version := body.Data.Metadata.Version
+		out = append(out, Content{ID: p.mount() + "/" + vp.Path, Group: "vault", Payload: string(b),
+			Revision: version, Version: version, Meta: map[string]string{"version": strconv.FormatInt(version, 10)}})
+	}
+	return out, nil
+}
+
+// vaultSignature 以路径与版本标识一组 secret。
+func vaultSignature(contents []Content) string {
+	var b strings.Builder
+	for _, c := range contents {
+		fmt.Fprintf(&b, "%s@%d;", c.ID, c.Version)
+	}
+	return b.String()
+}
+
+func (p *VaultProvider) Watch(onChange func() error) error {
+	return p.WatchContents(func([]Content) error { return onChange() })
+}
+
+// WatchContents 按 Interval 轮询版本，并在 AppRole 令牌续期后重新读取，内容变化时携带全部内容通知。
+func (p *VaultProvider) WatchContents(onChange func([]Content) error) error {
+	if p.Interval <= 0 && p.Token != "" {
+		return nil
+	}
+	if _, err := p.client(); err != nil {
+		return err
+	}
+	// 每次检查先等待下一次轮询或令牌续期，两者先到者触发
+	p.startWatch(0, p.RetryInterval, func(ctx context.Context) error {
+		var tick, renewC <-chan time.Time
+		if p.Interval > 0 {
+			tick = time.After(p.Interval)
+		}
+		p.mu.Lock()
+		renewAt := p.renewAt
+		p.mu.Unlock()
+		if !renewAt.IsZero() && p.Token == "" {
+			renewC = time.After(time.Until(renewAt))
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-tick:
+		case <-renewC:
+			if err := p.renew(ctx); err != nil {
+				return err
+			}
+		}
+		changed, out, err := p.check(ctx)
+		if changed {
+			_ = onChange(out)
+		}
+		return err
+	})
+	return nil
+}
+
+func (p *VaultProvider) check(ctx context.Context) (bool, []Content, error) {
+	out, err := p.read(ctx)
+	if err != nil {
+		return false, nil, err
+	}
+	sig := vaultSignature(out)
+	p.mu.Lock()
+	changed := sig != p.sig
+	p.sig = sig
+	p.mu.Unlock()
+	return changed, out, nil
+}
+
+// Close 停止监听。
+func (p *VaultProvider) Close() error {
+	p.stopWatch()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cli != nil {
+		p.cli.CloseIdleConnections()
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// vaultStub 模拟 Vault 的 KV v2 读取、AppRole 登录与令牌续期。
+type vaultStub struct {
+	mu       sync.Mutex
+	secrets  map[string]map[string]any // KV 路径 -> 数据
+	versions map[string]int64
+	tokens   map[string]bool // 有效令牌
+	ttl      int64           // AppRole 令牌的有效期（秒）
+	logins   int
+	renews   int
+	reads    int
+}
+
+func newVaultStub() *vaultStub {
+	return &vaultStub{secrets: map[string]map[string]any{}, versions: map[string]int64{},
+		tokens: map[string]bool{"root-token": true}, ttl: 60}
+}
+
+func (s *vaultStub) put(path string, data map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.secrets[path] = data
+	s.versions[path]++
+}
+
+func (s *vaultStub) count() (logins, renews, reads int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.logins, s.renews, s.reads
+}
+
+func (s *vaultStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deny := func() {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"errors":["permission denied"]}`)
+	}
+	auth := func(token string) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"auth": map[string]any{
+			"client_token": token, "lease_duration": s.ttl, "renewable": true}})
+	}
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/auth/approle/login":
+		var in map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		if in["role_id"] != "role" || in["secret_id"] != "sid" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"errors":["invalid role or secret ID"]}`)
+			return
+		}
+		s.logins++
+		token := fmt.Sprintf("approle-%d", s.logins)
+		s.tokens[token] = true
+		auth(token)
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/auth/token/renew-self":
+		token := r.Header.Get("X-Vault-Token")
+		if !s.tokens[token] {
+			deny()
+			return
+		}
+		s.renews++
+		auth(token)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v1/secret/data/"):
+		if !s.tokens[r.Header.Get("X-Vault-Token")] {
+			deny()
+			return
+		}
+		path := strings.TrimPrefix(r.URL.Path, "/v1/secret/data/")
+		data, ok := s.secrets[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errors":[]}`)
+			return
+		}
+		s.reads++
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{
+			"data": data, "metadata": map[string]any{"version": s.versions[path]}}})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestVault_TokenMountAt(t *testing.T) {
+	stub := newVaultStub()
+	stub.put("apps/demo/db", map[string]any{"password": "p@ss", "port": 5432})
+	stub.put("apps/demo/api", map[string]any{"key": "k1"})
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	p := NewVault(srv.URL, ParseVaultPaths("apps/demo/db=secrets.db,apps/demo/api", "secrets.api")...)
+	p.Token = "root-token"
+	cs, err := p.Open()
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if len(cs) != 2 || cs[0].ID != "secret/apps/demo/db" || cs[0].Version != 1 {
+		t.Fatalf("contents: %+v", cs)
+	}
+	if cs[0].Payload != "secrets:\n    db:\n        password: p@ss\n        port: 5432\n" {
+		t.Fatalf("db payload: %q", cs[0].Payload)
+	}
+	if cs[1].Payload != "secrets:\n    api:\n        key: k1\n" {
+		t.Fatalf("api payload: %q", cs[1].Payload)
+	}
+
+	p.Token = "bad-token"
+	if _, err := p.Open(); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("want permission error, got %v", err)
+	}
+}
+
+func TestVault_AppRoleWatch(t *testing.T) {
+	stub := newVaultStub()
+	stub.ttl = 1 // 令牌约 0.67s 后续期
+	stub.put("apps/demo/db", map[string]any{"password": "v1"})
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	p := NewVault(srv.URL, VaultPath{Path: "apps/demo/db", MountAt: "db"})
+	p.RoleID, p.SecretID = "role", "sid"
+	p.Interval = 20 * time.Millisecond
+	defer p.Close()
+	if _, err := p.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	got := make(chan []Content, 4)
+	if err := p.WatchContents(func(cs []Content) error { got <- cs; return nil }); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	stub.put("apps/demo/db", map[string]any{"password": "v2"})
+	select {
+	case cs := <-got:
+		if cs[0].Payload != "db:\n    password: v2\n" || cs[0].Version != 2 {
+			t.Fatalf("contents: %+v", cs)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("no change")
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		if logins, renews, _ := stub.count(); renews > 0 {
+			if logins != 1 {
+				t.Fatalf("renewal should not log in again: logins=%d", logins)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("token not renewed")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if !p.Health().OK {
+		t.Fatalf("want healthy: %+v", p.Health())
+	}
+}
+
+func TestVault_ReloginOnRevokedToken(t *testing.T) {
+	stub := newVaultStub()
+	stub.put("kv/app", map[string]any{"a": 1})
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	p := NewVault(srv.URL, VaultPath{Path: "kv/app"})
+	p.RoleID, p.SecretID = "role", "sid"
+	if _, err := p.Open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	stub.mu.Lock()
+	delete(stub.tokens, "approle-1")
+	stub.mu.Unlock()
+	cs, err := p.Open()
+	if err != nil || cs[0].Payload != "a: 1\n" {
+		t.Fatalf("reopen: %+v %v", cs, err)
+	}
+	if logins, _, _ := stub.count(); logins != 2 {
+		t.Fatalf("logins: %d", logins)
+	}
+
+	bad := NewVault(srv.URL, VaultPath{Path: "kv/app"})
+	bad.RoleID, bad.SecretID = "role", "wrong"
+	if _, err := bad.Open(); err == nil || !strings.Contains(err.Error(), "invalid role or secret ID") {
+		t.Fatalf("want login error, got %v", err)
+	}
+}
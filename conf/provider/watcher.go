@@ -0,0 +1,108 @@
+package provider
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxRetryInterval 是监听失败后退避的上限。
+const maxRetryInterval = 30 * time.Second
+
+// watcher 是轮询、长轮询与订阅类 Provider 共用的监听循环，负责失败退避、健康状态与停止，
+// Provider 嵌入它并只提供一次检查的逻辑。
+type watcher struct {
+	mu     sync.Mutex
+	health Health
+	cancel context.CancelFunc
+}
+
+// startWatch 在后台循环调用 check，已有的循环先被停止。每次调用前等待 interval，
+// interval 为 0 时立即调用，由 check 自身挂起（长轮询、阻塞查询、订阅）。
+// check 返回错误时标记为异常，从 retry（默认 1s）开始按倍数退避至 maxRetryInterval 后重试；
+// check 成功，或长时间运行的 check 中途调用 setHealth 报告正常后，退避重新从 retry 开始。
+// ctx 结束（Close）后 check 的返回值被忽略。
+func (w *watcher) startWatch(interval, retry time.Duration, check func(ctx context.Context) error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.mu.Lock()
+	if w.cancel != nil {
+		w.cancel()
+	}
+	w.cancel = cancel
+	w.health = Health{OK: true, Since: time.Now()}
+	w.mu.Unlock()
+	go w.loop(ctx, interval, retry, check)
+}
+
+func (w *watcher) loop(ctx context.Context, interval, retry time.Duration, check func(ctx context.Context) error) {
+	if retry <= 0 {
+		retry = time.Second
+	}
+	backoff, wait := retry, interval
+	for {
+		if wait > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+		}
+		err := check(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			wait = interval
+			w.setHealth(true, nil)
+			continue
+		}
+		w.mu.Lock()
+		recovered := w.health.OK
+		w.mu.Unlock()
+		if recovered {
+			backoff = retry
+		}
+		w.setHealth(false, err)
+		wait = backoff
+		if backoff *= 2; backoff > maxRetryInterval {
+			backoff = maxRetryInterval
+		}
+	}
+}
+
+// setHealth 记录一次检查的结果，只有状态在正常与异常之间切换时才更新 Since。
+func (w *watcher) setHealth(ok bool, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.health.OK != ok || w.health.Since.IsZero() {
+		w.health.Since = time.Now()
+	}
+	w.health.OK, w.health.Err = ok, err
+}
+
+// setRevision 记录最近一次成功检查时配置源的版本。
+func (w *watcher) setRevision(rev int64) {
+	w.mu.Lock()
+	w.health.Revision = rev
+	w.mu.Unlock()
+}
+
+// Health 返回监听的状态；未监听时视为正常。
+func (w *watcher) Health() Health {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cancel == nil {
+		return Health{OK: true}
+	}
+	return w.health
+}
+
+// stopWatch 停止监听循环，可重复调用。
+func (w *watcher) stopWatch() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cancel != nil {
+		w.cancel()
+		w.cancel = nil
+	}
+}
@@ -0,0 +1,411 @@
+// Package etcdtest 提供测试用的内存 etcd，供 conf/provider 与 loader 的测试共用。
+package etcdtest
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc"
+)
+
+// Server 是内存中的 etcd 替身，用于在没有 etcd 服务的环境下测试依赖 *clientv3.Client 的代码。
+// KV 在 gRPC 接口（pb.KVClient）层实现，由 clientv3 自己完成 Op 到请求的转换；
+// Watcher 与 Lease 实现 clientv3 接口的常用子集。它保存全部历史修订，支持按修订读取、前缀读取、监听与压缩。
+type Server struct {
+	mu        sync.Mutex
+	rev       int64
+	compacted int64
+	history   []*mvccpb.Event
+	watchers  map[*watch]struct{}
+	leaseID   int64
+	leases    map[clientv3.LeaseID]*lease
+}
+
+// New 返回一个空的 Server，初始修订号为 1。
+func New() *Server {
+	return &Server{rev: 1, watchers: map[*watch]struct{}{}, leases: map[clientv3.LeaseID]*lease{}}
+}
+
+// Client 返回一个以 Server 作为 KV、Watcher 与 Lease 的客户端。
+// 该客户端没有底层连接，不能调用 Close。
+func (s *Server) Client() *clientv3.Client {
+	return &clientv3.Client{KV: clientv3.NewKVFromKVClient(&kvClient{s: s}, nil), Watcher: &watchAPI{s: s}, Lease: &leaseAPI{s: s}}
+}
+
+// Rev 返回当前修订号。
+func (s *Server) Rev() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rev
+}
+
+// Put 写入一个键并返回新的修订号。
+func (s *Server) Put(key, val string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.put(key, val, 0)
+}
+
+func (s *Server) put(key, val string, leaseID clientv3.LeaseID) int64 {
+	for _, l := range s.leases {
+		delete(l.keys, key)
+	}
+	if l := s.leases[leaseID]; l != nil {
+		l.keys[key] = struct{}{}
+	}
+	s.rev++
+	prev := s.stateAt(s.rev - 1)[key]
+	kv := &mvccpb.KeyValue{Key: []byte(key), Value: []byte(val), ModRevision: s.rev, CreateRevision: s.rev, Version: 1, Lease: int64(leaseID)}
+	if prev != nil {
+		kv.CreateRevision = prev.CreateRevision
+		kv.Version = prev.Version + 1
+	}
+	s.append(&mvccpb.Event{Type: mvccpb.PUT, Kv: kv})
+	return s.rev
+}
+
+// Delete 删除一个键，键不存在时不产生新修订。
+func (s *Server) Delete(key string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.delete(key)
+}
+
+func (s *Server) delete(key string) int64 {
+	if s.stateAt(s.rev)[key] == nil {
+		return s.rev
+	}
+	s.rev++
+	s.append(&mvccpb.Event{Type: mvccpb.DELETE, Kv: &mvccpb.KeyValue{Key: []byte(key), ModRevision: s.rev}})
+	return s.rev
+}
+
+// Compact 压缩 rev 之前的历史，随后以更早修订读取或监听会得到 ErrCompacted。
+func (s *Server) Compact(rev int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.compacted = rev
+	for w := range s.watchers {
+		if w.next < rev {
+			w.send(clientv3.WatchResponse{Header: *s.header(), CompactRevision: rev, Canceled: true})
+			s.drop(w)
+		}
+	}
+}
+
+// CloseWatches 关闭所有监听通道，模拟连接中断或 leader 丢失。
+func (s *Server) CloseWatches() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for w := range s.watchers {
+		s.drop(w)
+	}
+}
+
+// Watchers 返回当前活跃的监听数量。
+func (s *Server) Watchers() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.watchers)
+}
+
+// Progress 向所有监听发送进度通知。
+func (s *Server) Progress() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for w := range s.watchers {
+		w.next = s.rev + 1
+		w.send(clientv3.WatchResponse{Header: *s.header()})
+	}
+}
+
+func (s *Server) header() *pb.ResponseHeader {
+	return &pb.ResponseHeader{Revision: s.rev}
+}
+
+func (s *Server) append(ev *mvccpb.Event) {
+	s.history = append(s.history, ev)
+	for w := range s.watchers {
+		if w.match(ev.Kv.Key) {
+			w.next = ev.Kv.ModRevision + 1
+			w.send(clientv3.WatchResponse{Header: *s.header(), Events: []*clientv3.Event{(*clientv3.Event)(ev)}})
+		}
+	}
+}
+
+func (s *Server) drop(w *watch) {
+	delete(s.watchers, w)
+	close(w.ch)
+}
+
+// stateAt 通过重放历史计算 rev 时刻的全部键值。
+func (s *Server) stateAt(rev int64) map[string]*mvccpb.KeyValue {
+	out := map[string]*mvccpb.KeyValue{}
+	for _, ev := range s.history {
+		if ev.Kv.ModRevision > rev {
+			break
+		}
+		if ev.Type == mvccpb.DELETE {
+			delete(out, string(ev.Kv.Key))
+			continue
+		}
+		out[string(ev.Kv.Key)] = ev.Kv
+	}
+	return out
+}
+
+func inRange(key, start, end []byte) bool {
+	switch {
+	case len(end) == 0:
+		return bytes.Equal(key, start)
+	case len(end) == 1 && end[0] == 0: // WithFromKey
+		return bytes.Compare(key, start) >= 0
+	}
+	return bytes.Compare(key, start) >= 0 && bytes.Compare(key, end) < 0
+}
+
+type kvClient struct {
+	pb.KVClient // Txn 与 Compact 未实现
+	s           *Server
+}
+
+func (k *kvClient) Range(ctx context.Context, r *pb.RangeRequest, _ ...grpc.CallOption) (*pb.RangeResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s := k.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rev := r.Revision
+	if rev == 0 {
+		rev = s.rev
+	}
+	if rev < s.compacted {
+		return nil, rpctypes.ErrGRPCCompacted
+	}
+	if rev > s.rev {
+		return nil, rpctypes.ErrGRPCFutureRev
+	}
+	resp := &pb.RangeResponse{Header: s.header()}
+	for k, v := range s.stateAt(rev) {
+		if inRange([]byte(k), r.Key, r.RangeEnd) {
+			resp.Kvs = append(resp.Kvs, v)
+		}
+	}
+	sort.Slice(resp.Kvs, func(i, j int) bool { return bytes.Compare(resp.Kvs[i].Key, resp.Kvs[j].Key) < 0 })
+	resp.Count = int64(len(resp.Kvs))
+	if r.Limit > 0 && int64(len(resp.Kvs)) > r.Limit {
+		resp.Kvs = resp.Kvs[:r.Limit]
+		resp.More = true
+	}
+	return resp, nil
+}
+
+func (k *kvClient) Put(ctx context.Context, r *pb.PutRequest, _ ...grpc.CallOption) (*pb.PutResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s := k.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	leaseID := clientv3.LeaseID(r.Lease)
+	if leaseID != 0 && s.leases[leaseID] == nil {
+		return nil, rpctypes.ErrGRPCLeaseNotFound
+	}
+	s.put(string(r.Key), string(r.Value), leaseID)
+	return &pb.PutResponse{Header: s.header()}, nil
+}
+
+func (k *kvClient) DeleteRange(ctx context.Context, r *pb.DeleteRangeRequest, _ ...grpc.CallOption) (*pb.DeleteRangeResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s := k.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for k := range s.stateAt(s.rev) {
+		if inRange([]byte(k), r.Key, r.RangeEnd) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s.delete(k)
+	}
+	return &pb.DeleteRangeResponse{Header: s.header(), Deleted: int64(len(keys))}, nil
+}
+
+type watch struct {
+	start, end []byte
+	next       int64
+	ch         chan clientv3.WatchResponse
+}
+
+func (w *watch) match(key []byte) bool { return inRange(key, w.start, w.end) }
+
+// send 不阻塞写入；测试中的消费者应及时读取。
+func (w *watch) send(resp clientv3.WatchResponse) {
+	select {
+	case w.ch <- resp:
+	default:
+	}
+}
+
+type watchAPI struct {
+	clientv3.Watcher
+	s *Server
+}
+
+func (a *watchAPI) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	op := clientv3.OpGet(key, opts...)
+	s := a.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w := &watch{start: op.KeyBytes(), end: op.RangeBytes(), next: s.rev + 1, ch: make(chan clientv3.WatchResponse, 256)}
+	if op.Rev() > 0 {
+		w.next = op.Rev()
+	}
+	if w.next < s.compacted {
+		w.send(clientv3.WatchResponse{Header: *s.header(), CompactRevision: s.compacted, Canceled: true})
+		close(w.ch)
+		return w.ch
+	}
+	w.send(clientv3.WatchResponse{Header: *s.header(), Created: true})
+	for _, ev := range s.history {
+		if ev.Kv.ModRevision >= w.next && w.match(ev.Kv.Key) {
+			w.send(clientv3.WatchResponse{Header: *s.header(), Events: []*clientv3.Event{(*clientv3.Event)(ev)}})
+		}
+	}
+	w.next = s.rev + 1
+	s.watchers[w] = struct{}{}
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.watchers[w]; ok {
+			s.drop(w)
+		}
+	}()
+	return w.ch
+}
+
+func (a *watchAPI) RequestProgress(ctx context.Context) error {
+	a.s.Progress()
+	return nil
+}
+
+func (a *watchAPI) Close() error {
+	a.s.CloseWatches()
+	return nil
+}
+
+type lease struct {
+	id    clientv3.LeaseID
+	ttl   int64
+	keys  map[string]struct{}
+	alive []chan *clientv3.LeaseKeepAliveResponse
+}
+
+// ExpireLease 使租约过期：删除关联的键并关闭 KeepAlive 通道。
+func (s *Server) ExpireLease(id clientv3.LeaseID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoke(id)
+}
+
+// Leases 返回当前有效的租约。
+func (s *Server) Leases() []clientv3.LeaseID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []clientv3.LeaseID
+	for id := range s.leases {
+		out = append(out, id)
+	}
+	return out
+}
+
+func (s *Server) revoke(id clientv3.LeaseID) bool {
+	l := s.leases[id]
+	if l == nil {
+		return false
+	}
+	delete(s.leases, id)
+	keys := make([]string, 0, len(l.keys))
+	for k := range l.keys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s.delete(k)
+	}
+	for _, ch := range l.alive {
+		close(ch)
+	}
+	return true
+}
+
+type leaseAPI struct {
+	clientv3.Lease
+	s *Server
+}
+
+func (a *leaseAPI) Grant(ctx context.Context, ttl int64) (*clientv3.LeaseGrantResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s := a.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.leaseID++
+	id := clientv3.LeaseID(s.leaseID)
+	s.leases[id] = &lease{id: id, ttl: ttl, keys: map[string]struct{}{}}
+	return &clientv3.LeaseGrantResponse{ResponseHeader: s.header(), ID: id, TTL: ttl}, nil
+}
+
+func (a *leaseAPI) Revoke(ctx context.Context, id clientv3.LeaseID) (*clientv3.LeaseRevokeResponse, error) {
+	s := a.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.revoke(id) {
+		return nil, rpctypes.ErrLeaseNotFound
+	}
+	return &clientv3.LeaseRevokeResponse{Header: s.header()}, nil
+}
+
+// KeepAlive 立即发送一次续约响应，随后保持通道打开直到租约过期或 ctx 结束。
+func (a *leaseAPI) KeepAlive(ctx context.Context, id clientv3.LeaseID) (<-chan *clientv3.LeaseKeepAliveResponse, error) {
+	s := a.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l := s.leases[id]
+	if l == nil {
+		return nil, rpctypes.ErrLeaseNotFound
+	}
+	ch := make(chan *clientv3.LeaseKeepAliveResponse, 1)
+	ch <- &clientv3.LeaseKeepAliveResponse{ID: id, TTL: l.ttl}
+	l.alive = append(l.alive, ch)
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if l := s.leases[id]; l != nil {
+			for i, c := range l.alive {
+				if c == ch {
+					l.alive = append(l.alive[:i], l.alive[i+1:]...)
+					close(ch)
+					break
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func (a *leaseAPI) Close() error { return nil }
@@ -3,29 +3,135 @@ package loader
 import (
     conf "config-loader/conf"
     provider "config-loader/conf/provider"
+    "io"
+    "io/fs"
+    "log/slog"
+    "os"
+    "os/signal"
+    "sync"
     "sync/atomic"
+    "time"
+
+    "github.com/nacos-group/nacos-sdk-go/v2/clients/config_client"
+    clientv3 "go.etcd.io/etcd/client/v3"
 )
 
 type Loader struct {
     p        provider.Provider
+    mu       sync.Mutex // 串行化监听与信号触发的加载
     cur      atomic.Value
+    rev      atomic.Int64
     onUpdate func(conf.Options)
+    reporter Reporter
+    status   Status
 }
 
 func New(p provider.Provider) *Loader { return &Loader{p: p} }
 
 func (l *Loader) Load() (conf.Options, error) {
-    opts, err := conf.LoadOptionsFromProvider(l.p)
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    contents, err := l.p.Open()
     if err != nil {
+        l.report(err)
+        return conf.Options{}, err
+    }
+    return l.apply(contents)
+}
+
+// apply 解析并应用一组内容，调用方需持有 l.mu。失败时保留当前配置。
+func (l *Loader) apply(contents []provider.Content) (conf.Options, error) {
+    opts, err := conf.LoadOptionsFromContents(contents)
+    if err != nil {
+        l.report(err)
         return opts, err
     }
     l.cur.Store(opts)
+    l.rev.Store(maxRevision(contents))
+    l.status = Status{Instance: l.status.Instance, Revision: l.rev.Load(), Hash: hashContents(contents), AppliedAt: time.Now()}
+    l.report(nil)
     if l.onUpdate != nil {
         l.onUpdate(opts)
     }
     return opts, nil
 }
 
+// report 更新最近一次加载的结果并交给 Reporter，调用方需持有 l.mu。
+// 失败时保留已生效的修订与摘要，仅记录错误。
+func (l *Loader) report(err error) {
+    if err != nil {
+        l.status.Error = err.Error()
+    }
+    if l.reporter != nil {
+        _ = l.reporter.Report(l.status)
+    }
+}
+
+// SetReporter 设置状态上报，每次加载后都会收到当前状态。
+func (l *Loader) SetReporter(r Reporter) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    l.reporter = r
+}
+
+// Status 返回最近一次加载的结果。
+func (l *Loader) Status() Status {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    return l.status
+}
+
+// Revision 返回当前配置对应的来源修订（各内容修订的最大值），来源不支持时为 0。
+func (l *Loader) Revision() int64 { return l.rev.Load() }
+
+func maxRevision(contents []provider.Content) int64 {
+    var rev int64
+    for _, c := range contents {
+        if c.Revision > rev {
+            rev = c.Revision
+        }
+    }
+    return rev
+}
+
+// Reload 强制重新读取配置，与 Load 走相同的解析、校验与通知流程，并在日志中记录结果。
+// 失败时保留当前配置。
+func (l *Loader) Reload(trigger string) error {
+    start := time.Now()
+    opts, err := l.Load()
+    if err != nil {
+        slog.Error("config reload failed", "trigger", trigger, "error", err)
+        return err
+    }
+    slog.Info("config reloaded", "trigger", trigger, "bind", opts.Server.Bind, "revision", l.Revision(), "elapsed", time.Since(start))
+    return nil
+}
+
+// WatchSignals 在收到指定信号（通常为 SIGHUP）时调用 Reload，
+// 与 Provider 自身的 Watch 并行工作。返回的函数用于停止监听。
+func (l *Loader) WatchSignals(sigs ...os.Signal) (stop func()) {
+    ch := make(chan os.Signal, 1)
+    signal.Notify(ch, sigs...)
+    done := make(chan struct{})
+    go func() {
+        for {
+            select {
+            case sig := <-ch:
+                _ = l.Reload("signal " + sig.String())
+            case <-done:
+                return
+            }
+        }
+    }()
+    var once sync.Once
+    return func() {
+        once.Do(func() {
+            signal.Stop(ch)
+            close(done)
+        })
+    }
+}
+
 func (l *Loader) Current() conf.Options {
     v := l.cur.Load()
     if v == nil {
@@ -35,18 +141,84 @@ func (l *Loader) Current() conf.Options {
     return v.(conf.Options)
 }
 
+// Health 返回来源监听的健康状态；来源不支持时视为正常。
+func (l *Loader) Health() provider.Health {
+    if hr, ok := l.p.(provider.HealthReporter); ok {
+        return hr.Health()
+    }
+    return provider.Health{OK: true}
+}
+
 func (l *Loader) SetOnUpdate(fn func(conf.Options)) { l.onUpdate = fn }
 
+// Watch 订阅来源变更。来源实现 provider.ContentWatcher 时直接应用事件携带的内容，
+// 否则在变更后重新 Open。
 func (l *Loader) Watch() error {
+    if cw, ok := l.p.(provider.ContentWatcher); ok {
+        return cw.WatchContents(func(contents []provider.Content) error {
+            l.mu.Lock()
+            defer l.mu.Unlock()
+            _, _ = l.apply(contents)
+            return nil
+        })
+    }
     return l.p.Watch(func() error { _, _ = l.Load(); return nil })
 }
 
+// Close 停止监听并释放 Provider 持有的连接（若 Provider 实现了 io.Closer）。
+func (l *Loader) Close() error {
+    if c, ok := l.p.(io.Closer); ok {
+        return c.Close()
+    }
+    return nil
+}
+
 func NewFile(path string) *Loader { return New(provider.NewFile(path)) }
 
 func NewEtcd(endpoints []string, key, user, pass string) *Loader {
     return New(provider.NewEtcd(endpoints, key, user, pass))
 }
 
+func NewEtcdPrefix(endpoints []string, prefix, user, pass string) *Loader {
+    return New(provider.NewEtcdPrefix(endpoints, prefix, user, pass))
+}
+
 func NewNacos(serverAddrs []string, namespaceID, group, dataID string) *Loader {
     return New(provider.NewNacos(serverAddrs, namespaceID, group, dataID))
-}
\ No newline at end of file
+}
+
+// NewEtcdWithClient 复用调用方已有的 etcd 客户端，Loader 不负责关闭它。
+func NewEtcdWithClient(cli *clientv3.Client, key string) *Loader {
+    return New(provider.NewEtcdWithClient(cli, key))
+}
+
+// NewNacosWithClient 复用调用方已有的 Nacos 客户端，Loader 不负责关闭它。
+func NewNacosWithClient(cli config_client.IConfigClient, group, dataID string) *Loader {
+    return New(provider.NewNacosWithClient(cli, group, dataID))
+}
+
+func NewEnv(prefix string) *Loader { return New(provider.NewEnv(prefix)) }
+
+func NewHTTP(url string) *Loader { return New(provider.NewHTTP(url)) }
+
+func NewConsul(address, key string) *Loader { return New(provider.NewConsul(address, key)) }
+
+func NewApollo(serverURL, appID string, namespaces ...string) *Loader {
+    return New(provider.NewApollo(serverURL, appID, namespaces...))
+}
+
+func NewGit(repo, ref, path string) *Loader { return New(provider.NewGit(repo, ref, path)) }
+
+func NewSQL(driver, dsn, table, group string) *Loader { return New(provider.NewSQL(driver, dsn, table, group)) }
+
+func NewRedis(addr, key string) *Loader { return New(provider.NewRedis(addr, key)) }
+
+func NewObjectStore(endpoint, bucket, key string) *Loader {
+    return New(provider.NewObjectStore(endpoint, bucket, key))
+}
+
+func NewVault(address string, paths ...provider.VaultPath) *Loader {
+    return New(provider.NewVault(address, paths...))
+}
+
+func NewFS(fsys fs.FS, patterns ...string) *Loader { return New(provider.NewFS(fsys, patterns...)) }
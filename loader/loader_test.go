@@ -0,0 +1,170 @@
+//go:build !windows
+
+package loader
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	conf "config-loader/conf"
+	provider "config-loader/conf/provider"
+)
+
+func TestLoader_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "c.yaml")
+	if err := os.WriteFile(path, []byte("server:\n  bind: ':1'\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	l := NewFile(path)
+	if _, err := l.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("server:\n  bind: ':2'\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := l.Reload("test"); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if l.Current().Server.Bind != ":2" {
+		t.Fatalf("bad bind: %s", l.Current().Server.Bind)
+	}
+	// 解析失败时保留当前配置
+	if err := os.WriteFile(path, []byte("server: [\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := l.Reload("test"); err == nil {
+		t.Fatalf("want error")
+	}
+	if l.Current().Server.Bind != ":2" {
+		t.Fatalf("bad bind: %s", l.Current().Server.Bind)
+	}
+}
+
+func TestLoader_WatchSignals(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "c.yaml")
+	if err := os.WriteFile(path, []byte("server:\n  bind: ':1'\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	l := NewFile(path)
+	if _, err := l.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	ch := make(chan conf.Options, 1)
+	l.SetOnUpdate(func(o conf.Options) { ch <- o })
+	stop := l.WatchSignals(syscall.SIGHUP)
+	defer stop()
+	if err := os.WriteFile(path, []byte("server:\n  bind: ':3'\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("kill: %v", err)
+	}
+	select {
+	case o := <-ch:
+		if o.Server.Bind != ":3" {
+			t.Fatalf("bad bind: %s", o.Server.Bind)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timeout")
+	}
+}
+
+type failProvider struct{}
+
+func (failProvider) Open() ([]provider.Content, error) { return nil, errors.New("unreachable") }
+func (failProvider) Watch(func() error) error          { return nil }
+
+func TestLoader_ReloadError(t *testing.T) {
+	l := New(failProvider{})
+	if err := l.Reload("test"); err == nil {
+		t.Fatalf("want error")
+	}
+}
+
+func TestLoader_HealthDefault(t *testing.T) {
+	l := New(failProvider{})
+	if !l.Health().OK {
+		t.Fatalf("want ok")
+	}
+}
+
+// pushProvider 通过 WatchContents 推送内容，并统计 Open 次数。
+type pushProvider struct {
+	opens int
+	push  func([]provider.Content) error
+}
+
+func (p *pushProvider) Open() ([]provider.Content, error) {
+	p.opens++
+	return []provider.Content{{ID: "k", Payload: "server:\n  bind: ':1'\n", Revision: 5}}, nil
+}
+func (p *pushProvider) Watch(func() error) error { return errors.New("not used") }
+func (p *pushProvider) WatchContents(fn func([]provider.Content) error) error {
+	p.push = fn
+	return nil
+}
+
+func TestLoader_WatchContents(t *testing.T) {
+	p := &pushProvider{}
+	l := New(p)
+	if _, err := l.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if l.Revision() != 5 {
+		t.Fatalf("bad revision: %d", l.Revision())
+	}
+	if err := l.Watch(); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	_ = p.push([]provider.Content{{ID: "k", Payload: "server:\n  bind: ':2'\n", Revision: 7}})
+	if l.Current().Server.Bind != ":2" || l.Revision() != 7 || p.opens != 1 {
+		t.Fatalf("bad state: %+v rev=%d opens=%d", l.Current(), l.Revision(), p.opens)
+	}
+	// 删除后没有可用内容时保留当前配置
+	_ = p.push([]provider.Content{{ID: "k", Revision: 8, Deleted: true}})
+	if l.Current().Server.Bind != ":2" || l.Revision() != 7 {
+		t.Fatalf("bad state after delete: %+v rev=%d", l.Current(), l.Revision())
+	}
+}
+
+// staticProvider 只支持 Watch，由测试触发变更，并统计 Open 次数。
+type staticProvider struct {
+	opens   int
+	payload string
+	change  func() error
+}
+
+func (p *staticProvider) Open() ([]provider.Content, error) {
+	p.opens++
+	return []provider.Content{{ID: "static", Payload: p.payload}}, nil
+}
+func (p *staticProvider) Watch(fn func() error) error { p.change = fn; return nil }
+
+func TestLoader_WatchContentsMulti(t *testing.T) {
+	push := &pushProvider{}
+	static := &staticProvider{payload: "welcome:\n  title: a\n"}
+	l := New(provider.NewMulti(static, push))
+	if _, err := l.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if err := l.Watch(); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	// 推送的内容与其他来源最近一次的内容合并，推送方不会被重新 Open
+	_ = push.push([]provider.Content{{ID: "k", Payload: "server:\n  bind: ':2'\n", Revision: 7}})
+	cur := l.Current()
+	if cur.Server.Bind != ":2" || cur.Welcome.Title != "a" || l.Revision() != 7 || push.opens != 1 || static.opens != 1 {
+		t.Fatalf("bad state: %+v rev=%d opens=%d/%d", cur, l.Revision(), push.opens, static.opens)
+	}
+	// 只支持 Watch 的来源变化时只重新读取它自己
+	static.payload = "welcome:\n  title: b\n"
+	_ = static.change()
+	cur = l.Current()
+	if cur.Server.Bind != ":2" || cur.Welcome.Title != "b" || push.opens != 1 || static.opens != 2 {
+		t.Fatalf("bad state after static change: %+v opens=%d/%d", cur, push.opens, static.opens)
+	}
+}
@@ -0,0 +1,230 @@
+package loader
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	provider "config-loader/conf/provider"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Status 描述一个实例当前应用的配置。
+type Status struct {
+	Instance  string    `json:"instance"`
+	Revision  int64     `json:"revision"`        // 生效配置对应的来源修订
+	Hash      string    `json:"hash"`            // 生效内容的 sha256
+	AppliedAt time.Time `json:"applied_at"`      // 最近一次成功应用的时间
+	Error     string    `json:"error,omitempty"` // 最近一次加载失败的原因，成功应用后清空
+}
+
+// Reporter 在每次加载（成功或失败）后接收实例状态。
+type Reporter interface {
+	Report(Status) error
+}
+
+// hashContents 计算生效内容的摘要，跳过已删除的条目。
+func hashContents(contents []provider.Content) string {
+	h := sha256.New()
+	for _, c := range contents {
+		if c.Deleted {
+			continue
+		}
+		h.Write([]byte(c.ID))
+		h.Write([]byte{0})
+		h.Write([]byte(c.Payload))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// EtcdReporter 将实例状态写入 etcd 的 <Prefix>/<App>/<Instance>，并绑定租约：
+// 实例退出或失联后租约过期，状态随之删除。写入在后台进行，Report 不会阻塞加载流程。
+type EtcdReporter struct {
+	Client   *clientv3.Client
+	Prefix   string // 默认 /status
+	App      string
+	Instance string
+	TTL      time.Duration // 租约时长，默认 30s
+
+	mu      sync.Mutex
+	latest  *Status
+	notify  chan struct{}
+	cancel  context.CancelFunc
+	done    chan struct{}
+	leaseID clientv3.LeaseID
+}
+
+func NewEtcdReporter(cli *clientv3.Client, app, instance string) *EtcdReporter {
+	return &EtcdReporter{Client: cli, Prefix: "/status", App: app, Instance: instance, TTL: 30 * time.Second}
+}
+
+// Key 返回本实例的状态键。
+func (r *EtcdReporter) Key() string {
+	return path.Join(r.prefix(), r.App, r.Instance)
+}
+
+func (r *EtcdReporter) prefix() string {
+	if r.Prefix == "" {
+		return "/status"
+	}
+	return r.Prefix
+}
+
+// Report 记录最新状态并唤醒后台写入，首次调用时启动后台循环。
+func (r *EtcdReporter) Report(s Status) error {
+	if r.App == "" || r.Instance == "" {
+		return errors.New("status reporter requires app and instance")
+	}
+	s.Instance = r.Instance
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latest = &s
+	if r.notify == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		r.notify = make(chan struct{}, 1)
+		r.cancel = cancel
+		r.done = make(chan struct{})
+		go r.loop(ctx)
+	}
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Close 停止后台写入并撤销租约，状态键随之删除。
+func (r *EtcdReporter) Close() error {
+	r.mu.Lock()
+	cancel, done := r.cancel, r.done
+	r.cancel = nil
+	r.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	<-done
+	if r.leaseID == 0 {
+		return nil
+	}
+	ctx, c := context.WithTimeout(context.Background(), 3*time.Second)
+	defer c()
+	_, err := r.Client.Revoke(ctx, r.leaseID)
+	return err
+}
+
+// loop 维护租约并写入最新状态；租约丢失（过期或续约中断）时重新申请并补写。
+func (r *EtcdReporter) loop(ctx context.Context) {
+	defer close(r.done)
+	ttl := r.TTL
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	var alive <-chan *clientv3.LeaseKeepAliveResponse
+	var kcancel context.CancelFunc = func() {}
+	defer func() { kcancel() }()
+	retry := time.NewTimer(0)
+	defer retry.Stop()
+	<-retry.C
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.notify:
+		case _, ok := <-alive:
+			if ok {
+				continue
+			}
+			alive = nil
+			r.leaseID = 0
+		case <-retry.C:
+		}
+		if r.leaseID == 0 {
+			kcancel()
+			id, ch, cancel, err := r.grant(ctx, ttl)
+			if err != nil {
+				retry.Reset(time.Second)
+				continue
+			}
+			r.leaseID, alive, kcancel = id, ch, cancel
+		}
+		if err := r.put(ctx); err != nil {
+			retry.Reset(time.Second)
+		}
+	}
+}
+
+func (r *EtcdReporter) grant(ctx context.Context, ttl time.Duration) (clientv3.LeaseID, <-chan *clientv3.LeaseKeepAliveResponse, context.CancelFunc, error) {
+	gctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	sec := int64(ttl / time.Second)
+	if sec < 1 {
+		sec = 1
+	}
+	resp, err := r.Client.Grant(gctx, sec)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	kctx, kcancel := context.WithCancel(ctx)
+	ch, err := r.Client.KeepAlive(kctx, resp.ID)
+	if err != nil {
+		kcancel()
+		return 0, nil, nil, err
+	}
+	return resp.ID, ch, kcancel, nil
+}
+
+func (r *EtcdReporter) put(ctx context.Context) error {
+	r.mu.Lock()
+	s := r.latest
+	r.mu.Unlock()
+	if s == nil {
+		return nil
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	pctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	_, err = r.Client.Put(pctx, r.Key(), string(b), clientv3.WithLease(r.leaseID))
+	return err
+}
+
+// ListStatus 列出 app 下所有存活实例的状态，按实例名排序。
+func ListStatus(ctx context.Context, cli *clientv3.Client, prefix, app string) ([]Status, error) {
+	if prefix == "" {
+		prefix = "/status"
+	}
+	resp, err := cli.Get(ctx, path.Join(prefix, app)+"/", clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Status, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var s Status
+		if err := json.Unmarshal(kv.Value, &s); err != nil {
+			continue
+		}
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Instance < out[j].Instance })
+	return out, nil
+}
+
+// ByRevision 将实例按生效修订分组，便于确认发布是否已全部生效。
+func ByRevision(statuses []Status) map[int64][]string {
+	out := map[int64][]string{}
+	for _, s := range statuses {
+		out[s.Revision] = append(out[s.Revision], s.Instance)
+	}
+	return out
+}
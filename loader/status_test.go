@@ -0,0 +1,113 @@
+package loader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	provider "config-loader/conf/provider"
+	"config-loader/internal/etcdtest"
+)
+
+// flakyProvider 在 err 非空时加载失败，否则返回固定内容。
+type flakyProvider struct {
+	payload string
+	err     error
+}
+
+func (p *flakyProvider) Open() ([]provider.Content, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return []provider.Content{{ID: "k", Payload: p.payload, Revision: 1}}, nil
+}
+func (p *flakyProvider) Watch(func() error) error { return nil }
+
+type recordReporter struct{ got []Status }
+
+func (r *recordReporter) Report(s Status) error {
+	r.got = append(r.got, s)
+	return nil
+}
+
+func TestLoader_StatusReport(t *testing.T) {
+	p := &flakyProvider{payload: "server:\n  bind: \":1\"\n"}
+	l := New(p)
+	rr := &recordReporter{}
+	l.SetReporter(rr)
+	if _, err := l.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	first := l.Status()
+	if first.Hash == "" || first.AppliedAt.IsZero() || first.Error != "" {
+		t.Fatalf("unexpected status: %+v", first)
+	}
+	p.err = errors.New("boom")
+	if _, err := l.Load(); err == nil {
+		t.Fatalf("expected error")
+	}
+	st := l.Status()
+	if st.Error != "boom" || st.Hash != first.Hash || !st.AppliedAt.Equal(first.AppliedAt) {
+		t.Fatalf("failure should keep applied config: %+v", st)
+	}
+	if len(rr.got) != 2 || rr.got[1].Error != "boom" {
+		t.Fatalf("reports: %+v", rr.got)
+	}
+}
+
+func waitStatus(t *testing.T, s *etcdtest.Server, key string, ok func(Status) bool) {
+	t.Helper()
+	cli := s.Client()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		resp, err := cli.Get(context.Background(), key)
+		if err == nil && len(resp.Kvs) == 1 {
+			var st Status
+			if json.Unmarshal(resp.Kvs[0].Value, &st) == nil && ok(st) {
+				return
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("status %s not reached", key)
+}
+
+func TestEtcdReporter(t *testing.T) {
+	s := etcdtest.New()
+	r := NewEtcdReporter(s.Client(), "svc", "a")
+	defer r.Close()
+	if err := r.Report(Status{Revision: 3, Hash: "h"}); err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	waitStatus(t, s, "/status/svc/a", func(st Status) bool { return st.Revision == 3 && st.Instance == "a" })
+
+	// 租约过期后重新申请并补写
+	for _, id := range s.Leases() {
+		s.ExpireLease(id)
+	}
+	waitStatus(t, s, "/status/svc/a", func(st Status) bool { return st.Revision == 3 })
+	if len(s.Leases()) != 1 {
+		t.Fatalf("expected a new lease, got %v", s.Leases())
+	}
+
+	s.Put("/status/svc/b", `{"instance":"b","revision":2}`)
+	s.Put("/status/svc/c", `{"instance":"c","revision":3}`)
+	list, err := ListStatus(context.Background(), s.Client(), "", "svc")
+	if err != nil || len(list) != 3 || list[0].Instance != "a" {
+		t.Fatalf("list: %+v %v", list, err)
+	}
+	groups := ByRevision(list)
+	if len(groups[3]) != 2 || len(groups[2]) != 1 {
+		t.Fatalf("groups: %+v", groups)
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	resp, _ := s.Client().Get(context.Background(), r.Key())
+	if len(resp.Kvs) != 0 {
+		t.Fatalf("status should be removed on close")
+	}
+}
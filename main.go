@@ -2,50 +2,362 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"embed"
 	"flag"
+	"fmt"
 	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
 	"strings"
 	"sync/atomic"
+	"syscall"
 	"time"
 
 	conf "config-loader/conf"
+	provider "config-loader/conf/provider"
 	loader "config-loader/loader"
 
 	"github.com/bytedance/sonic"
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/app/server"
+	"gopkg.in/yaml.v3"
+
+	// -source sql 可用的数据库驱动
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
 )
 
 var welcome atomic.Value // string
 
+// defaultConfig 为编译进二进制的基线配置，供 -source embed 使用。
+//
+//go:embed config.yaml
+var defaultConfig embed.FS
+
 // main 负责解析参数、选择 Provider，并启动 HTTP 服务与监听。
 // 配置解析逻辑由 conf 包提供；provider 包仅负责配置来源接口。
 func main() {
-	source := flag.String("source", "file", "config source: file|etcd|nacos")
+	source := flag.String("source", "file", "config source(s), comma-separated and merged in order: file|etcd|nacos|env|exec|http|consul|apollo|git|sql|redis|s3|vault|fs|embed")
 	cfgPath := flag.String("config", "./config.yaml", "config file path (for file source)")
 	etcdEndpoints := flag.String("etcd-endpoints", "", "comma-separated etcd endpoints (for etcd source)")
 	etcdKey := flag.String("etcd-key", "", "etcd key holding YAML config (for etcd source)")
+	etcdPrefix := flag.Bool("etcd-prefix", false, "treat -etcd-key as a prefix and merge every key under it in key order")
 	etcdUser := flag.String("etcd-user", "", "etcd username (optional)")
 	etcdPass := flag.String("etcd-pass", "", "etcd password (optional)")
-	nacosServers := flag.String("nacos-servers", "", "comma-separated nacos server addrs host:port (for nacos source)")
+	etcdCACert := flag.String("etcd-cacert", "", "CA bundle to verify etcd servers (enables TLS)")
+	etcdCert := flag.String("etcd-cert", "", "client certificate for etcd mutual TLS")
+	etcdKeyFile := flag.String("etcd-key-file", "", "client private key for etcd mutual TLS")
+	etcdServerName := flag.String("etcd-server-name", "", "override the server name used to verify etcd certificates (required for several IP endpoints with -etcd-cacert)")
+	etcdInsecure := flag.Bool("etcd-insecure-skip-verify", false, "skip etcd server certificate verification (dev only)")
+	etcdRev := flag.Int64("etcd-rev", 0, "load etcd config as of this historical revision and do not watch (0 = latest)")
+	etcdHistory := flag.Int("etcd-history", 0, "print the last N revisions of -etcd-key (of every key under it with -etcd-prefix) and exit; etcd keeps no write time, so entries are listed by revision only")
+	dump := flag.Bool("dump", false, "print the effective merged config as YAML and exit")
+	statusApp := flag.String("status-app", "", "publish applied-config status to etcd under /status/<app>/<instance> (uses -etcd-* connection flags)")
+	statusInstance := flag.String("status-instance", "", "instance id for status reporting (default: hostname)")
+	statusTTL := flag.Duration("status-ttl", 30*time.Second, "lease TTL of the status record")
+	statusList := flag.Bool("status-list", false, "print instances of -status-app grouped by applied revision and exit")
+	nacosServers := flag.String("nacos-servers", "", "comma-separated nacos server addrs [http(s)://]host[:port][/contextPath] (for nacos source)")
+	nacosGrpcOffset := flag.Uint64("nacos-grpc-port-offset", 1000, "gRPC port offset from the nacos HTTP port")
 	nacosNS := flag.String("nacos-namespace", "", "nacos namespace id (optional)")
 	nacosGroup := flag.String("nacos-group", "DEFAULT_GROUP", "nacos group")
 	nacosDataID := flag.String("nacos-dataid", "", "nacos dataId holding YAML config")
+	nacosUser := flag.String("nacos-username", "", "nacos auth username (file:/path and env:NAME are resolved)")
+	nacosPass := flag.String("nacos-password", "", "nacos auth password (file:/path and env:NAME are resolved)")
+	nacosAK := flag.String("nacos-access-key", "", "Alibaba Cloud AccessKey (file:/path and env:NAME are resolved)")
+	nacosSK := flag.String("nacos-secret-key", "", "Alibaba Cloud SecretKey (file:/path and env:NAME are resolved)")
+	nacosEndpoint := flag.String("nacos-endpoint", "", "address server endpoint used to discover nacos servers instead of -nacos-servers")
+	nacosRegion := flag.String("nacos-region-id", "", "Alibaba Cloud region id (for KMS)")
+	nacosKMS := flag.Bool("nacos-kms", false, "decrypt cipher- dataIds with Alibaba Cloud KMS")
+	nacosCacheDir := flag.String("nacos-cache-dir", "", "directory for nacos SDK cache and config snapshots")
+	nacosFailover := flag.Bool("nacos-failover", false, "start from the snapshot in -nacos-cache-dir when nacos is unreachable")
+	nacosAppName := flag.String("nacos-app-name", "", "app name reported to nacos")
+	nacosTag := flag.String("nacos-tag", "", "read the config variant with this tag, falling back to the normal config")
+	nacosBeta := flag.Bool("nacos-detect-beta", false, "record whether a beta (gray) config is served to this instance")
+	nacosDataIDs := flag.String("nacos-shared-dataids", "", "comma-separated shared/extension dataIds loaded before -nacos-dataid, each dataId[@group][;refresh=false]")
+	envPrefix := flag.String("env-prefix", "APP_", "environment variable prefix, nested keys joined by __ (for env source)")
+	execCmd := flag.String("exec-cmd", "", "command whose stdout is the YAML config, split on spaces unless -exec-arg is given (for exec source)")
+	var execArgs stringList
+	flag.Var(&execArgs, "exec-arg", "argument passed verbatim to -exec-cmd, repeatable; when set, -exec-cmd is the program path and is not split")
+	execTimeout := flag.Duration("exec-timeout", 30*time.Second, "exec command timeout")
+	execInterval := flag.Duration("exec-interval", 0, "re-run exec command periodically (0 disables; SIGHUP always re-runs)")
+	httpURL := flag.String("http-url", "", "URL serving the YAML config (for http source)")
+	httpHeaders := flag.String("http-headers", "", "comma-separated extra request headers, each Name=Value")
+	httpToken := flag.String("http-token", "", "bearer token (file:/path and env:NAME are resolved)")
+	httpInterval := flag.Duration("http-interval", 30*time.Second, "poll interval (0 disables polling)")
+	httpLongPoll := flag.Bool("http-long-poll", false, "long-poll: the server holds conditional requests until the config changes")
+	httpCACert := flag.String("http-cacert", "", "CA bundle to verify the HTTP server")
+	httpCert := flag.String("http-cert", "", "client certificate for HTTP mutual TLS")
+	httpKeyFile := flag.String("http-key-file", "", "client private key for HTTP mutual TLS")
+	httpInsecure := flag.Bool("http-insecure-skip-verify", false, "skip HTTP server certificate verification (dev only)")
+	consulAddr := flag.String("consul-addr", "http://127.0.0.1:8500", "consul HTTP address (for consul source)")
+	consulKey := flag.String("consul-key", "", "consul KV key holding YAML config (for consul source)")
+	consulPrefix := flag.Bool("consul-prefix", false, "treat -consul-key as a prefix and merge every key under it in key order")
+	consulToken := flag.String("consul-token", "", "consul ACL token (file:/path and env:NAME are resolved)")
+	consulDC := flag.String("consul-dc", "", "consul datacenter (default: the agent's)")
+	apolloURL := flag.String("apollo-url", "http://127.0.0.1:8080", "apollo config service address (for apollo source)")
+	apolloAppID := flag.String("apollo-app-id", "", "apollo app id (for apollo source)")
+	apolloCluster := flag.String("apollo-cluster", "default", "apollo cluster")
+	apolloNamespaces := flag.String("apollo-namespaces", "application", "comma-separated apollo namespaces, merged in order")
+	apolloSecret := flag.String("apollo-secret", "", "apollo access key secret for signed requests (file:/path and env:NAME are resolved)")
+	gitRepo := flag.String("git-repo", "", "local git repository path (for git source)")
+	gitRef := flag.String("git-ref", "HEAD", "branch, tag or commit to read config from")
+	gitPath := flag.String("git-path", "", "file or directory inside the repository; directories merge their yaml/yml/json files in path order")
+	gitRemote := flag.String("git-remote", "", "remote to fetch -git-ref from before each check (optional)")
+	gitInterval := flag.Duration("git-interval", 0, "interval to check for new commits (0 = no watch)")
+	sqlDriver := flag.String("sql-driver", "sqlite", "database driver: sqlite|mysql|postgres (for sql source)")
+	sqlDSN := flag.String("sql-dsn", "", "database DSN (file:/path and env:NAME are resolved)")
+	sqlTable := flag.String("sql-table", "config_items", "table with id, group, format, payload and version columns")
+	sqlGroup := flag.String("sql-group", "", "only read rows whose group column equals this value (default: all rows)")
+	sqlVersionCol := flag.String("sql-version-column", "version", "column polled to detect changes, e.g. updated_at")
+	sqlInterval := flag.Duration("sql-interval", 0, "interval to poll the version column (0 = no watch)")
+	redisAddr := flag.String("redis-addr", "127.0.0.1:6379", "redis address, host:port or redis:// / rediss:// URL (for redis source)")
+	redisKey := flag.String("redis-key", "", "redis string or hash key holding config (for redis source)")
+	redisPattern := flag.Bool("redis-pattern", false, "treat -redis-key as a glob pattern and merge every matching key in key order")
+	redisUser := flag.String("redis-user", "", "redis ACL username (optional)")
+	redisPass := flag.String("redis-pass", "", "redis password (file:/path and env:NAME are resolved)")
+	redisDB := flag.Int("redis-db", 0, "redis database number")
+	redisNotify := flag.String("redis-notify", "auto", "watch via keyspace notifications: auto|on|off (off = poll only)")
+	redisInterval := flag.Duration("redis-interval", 5*time.Second, "poll interval when keyspace notifications are unavailable")
+	redisCACert := flag.String("redis-cacert", "", "CA bundle to verify redis servers (enables TLS)")
+	redisCert := flag.String("redis-cert", "", "client certificate for redis mutual TLS")
+	redisKeyFile := flag.String("redis-key-file", "", "client private key for redis mutual TLS")
+	s3Endpoint := flag.String("s3-endpoint", "", "S3-compatible endpoint, e.g. http://minio:9000 (default: AWS S3 in -s3-region)")
+	s3Region := flag.String("s3-region", "us-east-1", "S3 region used for SigV4 signing")
+	s3Bucket := flag.String("s3-bucket", "", "bucket holding config objects (for s3 source)")
+	s3Key := flag.String("s3-key", "", "object key holding YAML config (for s3 source)")
+	s3Prefix := flag.Bool("s3-prefix", false, "treat -s3-key as a prefix and merge every object under it in key order")
+	s3PathStyle := flag.Bool("s3-path-style", false, "use path-style addressing (<endpoint>/<bucket>/<key>), needed by MinIO")
+	s3AccessKey := flag.String("s3-access-key", "", "S3 access key (file:/path and env:NAME are resolved; default: $AWS_ACCESS_KEY_ID, anonymous if unset)")
+	s3SecretKey := flag.String("s3-secret-key", "", "S3 secret key (file:/path and env:NAME are resolved; default: $AWS_SECRET_ACCESS_KEY)")
+	s3Interval := flag.Duration("s3-interval", 0, "interval to poll object ETags (0 = no watch)")
+	vaultAddr := flag.String("vault-addr", "http://127.0.0.1:8200", "vault address (for vault source)")
+	vaultToken := flag.String("vault-token", "", "vault token (file:/path and env:NAME are resolved)")
+	vaultRoleID := flag.String("vault-role-id", "", "approle role id, used when -vault-token is empty (file:/path and env:NAME are resolved)")
+	vaultSecretID := flag.String("vault-secret-id", "", "approle secret id (file:/path and env:NAME are resolved)")
+	vaultNamespace := flag.String("vault-namespace", "", "vault enterprise namespace (optional)")
+	vaultMount := flag.String("vault-mount", "secret", "KV v2 secrets engine mount path")
+	vaultPaths := flag.String("vault-paths", "", "comma-separated secret paths, each optionally path=subtree (e.g. apps/demo/db=secrets.db)")
+	vaultMountAt := flag.String("vault-mount-at", "", "dotted config subtree for paths without =subtree (default: config root)")
+	vaultInterval := flag.Duration("vault-interval", 0, "interval to poll secret versions (0 = only re-read on token renewal)")
+	vaultCACert := flag.String("vault-cacert", "", "CA bundle to verify the vault server")
+	fsDir := flag.String("fs-dir", "", "directory to read config files from (for fs source)")
+	fsArchive := flag.String("fs-archive", "", "zip, tar or tar.gz bundle to read config files from, instead of -fs-dir")
+	fsPatterns := flag.String("fs-patterns", "", "comma-separated glob patterns merged in order; ** matches any directories (default **/*.{yaml,yml})")
+	fsInterval := flag.Duration("fs-interval", 0, "interval to re-read the directory or bundle (0 = no watch)")
 	flag.Parse()
 
-	// 初始化 Loader
+	etcdTLS := provider.TLSOptions{
+		CAFile:             *etcdCACert,
+		CertFile:           *etcdCert,
+		KeyFile:            *etcdKeyFile,
+		ServerName:         *etcdServerName,
+		InsecureSkipVerify: *etcdInsecure,
+	}
+
+	// 初始化 Loader，多个来源按顺序合并；连接参数相同的 etcd/Nacos 来源共享客户端
+	pool := provider.NewClientPool()
+	var providers []provider.Provider
+	var etcdP *provider.EtcdProvider
+	for _, name := range nonEmpty(strings.Split(*source, ",")) {
+		switch name {
+		case "file":
+			providers = append(providers, provider.NewFile(*cfgPath))
+		case "etcd":
+			eps := strings.Split(strings.TrimSpace(*etcdEndpoints), ",")
+			p := provider.NewEtcd(nonEmpty(eps), *etcdKey, *etcdUser, *etcdPass)
+			p.Prefix = *etcdPrefix
+			p.TLS = etcdTLS
+			p.AtRevision = *etcdRev
+			p.Pool = pool
+			etcdP = p
+			providers = append(providers, p)
+		case "nacos":
+			eps := strings.Split(strings.TrimSpace(*nacosServers), ",")
+			p := provider.NewNacos(nonEmpty(eps), *nacosNS, *nacosGroup, *nacosDataID)
+			configs, err := provider.ParseNacosConfigs(*nacosDataIDs)
+			if err != nil {
+				slog.Error("invalid -nacos-shared-dataids", "error", err)
+				return
+			}
+			p.Configs = configs
+			p.Username, p.Password = *nacosUser, *nacosPass
+			p.AccessKey, p.SecretKey = *nacosAK, *nacosSK
+			p.Endpoint, p.RegionID, p.OpenKMS = *nacosEndpoint, *nacosRegion, *nacosKMS
+			p.CacheDir, p.Failover = *nacosCacheDir, *nacosFailover
+			p.GrpcPortOffset = *nacosGrpcOffset
+			p.AppName, p.Tag, p.DetectBeta = *nacosAppName, *nacosTag, *nacosBeta
+			p.Pool = pool
+			providers = append(providers, p)
+		case "http":
+			p := provider.NewHTTP(*httpURL)
+			p.BearerToken = *httpToken
+			p.Interval = *httpInterval
+			p.LongPoll = *httpLongPoll
+			p.TLS = provider.TLSOptions{CAFile: *httpCACert, CertFile: *httpCert, KeyFile: *httpKeyFile, InsecureSkipVerify: *httpInsecure}
+			p.Headers = map[string]string{}
+			for _, h := range nonEmpty(strings.Split(*httpHeaders, ",")) {
+				k, v, ok := strings.Cut(h, "=")
+				if !ok {
+					slog.Error("invalid -http-headers entry, want Name=Value", "entry", h)
+					return
+				}
+				p.Headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+			}
+			providers = append(providers, p)
+		case "consul":
+			p := provider.NewConsul(*consulAddr, *consulKey)
+			p.Prefix = *consulPrefix
+			p.Token = *consulToken
+			p.Datacenter = *consulDC
+			providers = append(providers, p)
+		case "git":
+			p := provider.NewGit(*gitRepo, *gitRef, *gitPath)
+			p.Remote = *gitRemote
+			p.Interval = *gitInterval
+			providers = append(providers, p)
+		case "sql":
+			p := provider.NewSQL(*sqlDriver, *sqlDSN, *sqlTable, *sqlGroup)
+			p.VersionColumn = *sqlVersionCol
+			p.Interval = *sqlInterval
+			providers = append(providers, p)
+		case "redis":
+			p := provider.NewRedis(*redisAddr, *redisKey)
+			p.Pattern = *redisPattern
+			p.Username, p.Password, p.DB = *redisUser, *redisPass, *redisDB
+			p.TLS = provider.TLSOptions{CAFile: *redisCACert, CertFile: *redisCert, KeyFile: *redisKeyFile}
+			p.Notify = *redisNotify
+			p.Interval = *redisInterval
+			providers = append(providers, p)
+		case "s3":
+			p := provider.NewObjectStore(*s3Endpoint, *s3Bucket, *s3Key)
+			p.Region, p.Prefix, p.PathStyle = *s3Region, *s3Prefix, *s3PathStyle
+			p.AccessKey, p.SecretKey = *s3AccessKey, *s3SecretKey
+			if p.AccessKey == "" && os.Getenv("AWS_ACCESS_KEY_ID") != "" {
+				// 与 AWS CLI 一致，未指定凭据时读取环境变量
+				p.AccessKey, p.SecretKey = "env:AWS_ACCESS_KEY_ID", "env:AWS_SECRET_ACCESS_KEY"
+				p.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+			}
+			p.Interval = *s3Interval
+			providers = append(providers, p)
+		case "vault":
+			p := provider.NewVault(*vaultAddr, provider.ParseVaultPaths(*vaultPaths, *vaultMountAt)...)
+			p.Token, p.RoleID, p.SecretID = *vaultToken, *vaultRoleID, *vaultSecretID
+			p.Namespace, p.Mount = *vaultNamespace, *vaultMount
+			p.TLS = provider.TLSOptions{CAFile: *vaultCACert}
+			p.Interval = *vaultInterval
+			providers = append(providers, p)
+		case "fs":
+			patterns := nonEmpty(strings.Split(*fsPatterns, ","))
+			var p *provider.FSProvider
+			switch {
+			case *fsArchive != "":
+				p = provider.NewArchive(*fsArchive, patterns...)
+			case *fsDir != "":
+				p = provider.NewDirFS(*fsDir, patterns...)
+			default:
+				slog.Error("fs source requires -fs-dir or -fs-archive")
+				return
+			}
+			p.Interval = *fsInterval
+			providers = append(providers, p)
+		case "embed":
+			providers = append(providers, provider.NewFS(defaultConfig, "config.yaml"))
+		case "apollo":
+			p := provider.NewApollo(*apolloURL, *apolloAppID, nonEmpty(strings.Split(*apolloNamespaces, ","))...)
+			p.Cluster = *apolloCluster
+			p.Secret = *apolloSecret
+			providers = append(providers, p)
+		case "env":
+			providers = append(providers, provider.NewEnv(*envPrefix))
+		case "exec":
+			args := strings.Fields(*execCmd)
+			if len(execArgs) > 0 {
+				args = append([]string{*execCmd}, execArgs...)
+			}
+			if *execCmd == "" {
+				slog.Error("exec source requires -exec-cmd")
+				return
+			}
+			p := provider.NewExec(args[0], args[1:]...)
+			p.Timeout = *execTimeout
+			p.Interval = *execInterval
+			providers = append(providers, p)
+		default:
+			slog.Error("unknown source", "source", name)
+			return
+		}
+	}
 	var l *loader.Loader
-	switch *source {
-	case "file":
-		l = loader.NewFile(*cfgPath)
-	case "etcd":
-		eps := strings.Split(strings.TrimSpace(*etcdEndpoints), ",")
-		l = loader.NewEtcd(nonEmpty(eps), *etcdKey, *etcdUser, *etcdPass)
-	case "nacos":
-		eps := strings.Split(strings.TrimSpace(*nacosServers), ",")
-		l = loader.NewNacos(nonEmpty(eps), *nacosNS, *nacosGroup, *nacosDataID)
+	switch len(providers) {
+	case 0:
+		slog.Error("no config source", "source", *source)
+		return
+	case 1:
+		l = loader.New(providers[0])
 	default:
-		slog.Error("unknown source", "source", *source)
+		l = loader.New(provider.NewMulti(providers...))
+	}
+
+	defer l.Close()
+
+	if *statusApp != "" {
+		cli, release, err := pool.Etcd(provider.EtcdClientOptions{
+			Endpoints:   nonEmpty(strings.Split(*etcdEndpoints, ",")),
+			Username:    *etcdUser,
+			Password:    *etcdPass,
+			DialTimeout: 5 * time.Second,
+			TLS:         etcdTLS,
+		})
+		if err != nil {
+			slog.Error("create etcd client for status failed", "error", err)
+			return
+		}
+		defer release()
+		if *statusList {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			statuses, err := loader.ListStatus(ctx, cli, "", *statusApp)
+			if err != nil {
+				slog.Error("list status failed", "error", err)
+				return
+			}
+			groups := loader.ByRevision(statuses)
+			revs := make([]int64, 0, len(groups))
+			for rev := range groups {
+				revs = append(revs, rev)
+			}
+			sort.Slice(revs, func(i, j int) bool { return revs[i] > revs[j] })
+			for _, rev := range revs {
+				fmt.Printf("revision=%d instances=%d %s\n", rev, len(groups[rev]), strings.Join(groups[rev], ","))
+			}
+			return
+		}
+		instance := *statusInstance
+		if instance == "" {
+			instance, _ = os.Hostname()
+		}
+		r := loader.NewEtcdReporter(cli, *statusApp, instance)
+		r.TTL = *statusTTL
+		defer r.Close()
+		l.SetReporter(r)
+	}
+
+	if *etcdHistory > 0 {
+		if etcdP == nil {
+			slog.Error("-etcd-history requires the etcd source")
+			return
+		}
+		revs, err := etcdP.History("", *etcdHistory)
+		if err != nil {
+			slog.Error("list etcd history failed", "error", err)
+			return
+		}
+		for _, r := range revs {
+			fmt.Printf("key=%s revision=%d version=%d bytes=%d sha256=%x\n", r.Key, r.Revision, r.Version, len(r.Payload), sha256.Sum256([]byte(r.Payload)))
+		}
 		return
 	}
 
@@ -55,6 +367,15 @@ func main() {
 		slog.Error("failed to load config", "error", err)
 		return
 	}
+	if *dump {
+		out, err := yaml.Marshal(opts)
+		if err != nil {
+			slog.Error("marshal config failed", "error", err)
+			return
+		}
+		fmt.Printf("# revision: %d\n%s", l.Revision(), out)
+		return
+	}
 	var optsVal atomic.Value
 	optsVal.Store(opts)
 	// 初始化欢迎（用于承载完整配置的 JSON 字符串），防止首次请求取值为 nil
@@ -70,6 +391,8 @@ func main() {
 		server.WithDisablePrintRoute(true),
 		server.WithExitWaitTime(1*time.Second),
 	)
+	// hertz 默认把 SIGHUP 也当作退出信号，这里改为只响应 SIGINT/SIGTERM
+	h.SetCustomSignalWaiter(waitStopSignal)
 
 	// 监听来源变更，动态刷新 opts
 	l.SetOnUpdate(func(newOpts conf.Options) {
@@ -81,6 +404,8 @@ func main() {
 	if err := l.Watch(); err != nil {
 		slog.Error("start config watch failed", "error", err)
 	}
+	// kill -HUP 强制重新读取配置，适用于 etcd/Nacos 连接抖动后的补偿
+	l.WatchSignals(syscall.SIGHUP)
 
 	h.GET("/", func(ctx context.Context, c *app.RequestContext) {
 		v := welcome.Load()
@@ -94,12 +419,48 @@ func main() {
 	})
 
 	h.GET("/health", func(ctx context.Context, c *app.RequestContext) {
+		wh := l.Health()
+		if !wh.OK {
+			// 监听中断时仍在使用最后一次成功加载的配置
+			resp := map[string]any{"status": "degraded", "since": wh.Since, "revision": wh.Revision}
+			if wh.Err != nil {
+				resp["error"] = wh.Err.Error()
+			}
+			c.JSON(200, resp)
+			return
+		}
 		c.JSON(200, map[string]string{"status": "ok"})
 	})
 
 	h.Spin()
 }
 
+// waitStopSignal 在收到 SIGINT/SIGTERM 或服务出错时返回，触发 hertz 退出；
+// SIGHUP 留给配置重载，不会停止服务。
+func waitStopSignal(errCh chan error) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	select {
+	case sig := <-signals:
+		slog.Info("received signal, shutting down", "signal", sig.String())
+		return nil
+	case err := <-errCh:
+		return err
+	}
+}
+
+// stringList 是可重复指定的字符串参数。
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, " ") }
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
 // nonEmpty 过滤空字符串元素
 func nonEmpty(items []string) []string {
 	var out []string